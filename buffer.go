@@ -0,0 +1,128 @@
+package wlog
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultBufferSize is the number of entries held by FingersCrossed
+// unless another size is given
+const DefaultBufferSize = 100
+
+// bufferedEntry is an entry held by a scopeBuffer
+type bufferedEntry struct {
+	logLevel     LogLevel
+	msg          string
+	timestamp    time.Time
+	fields       Fields
+	typed        []Field
+	fieldMapping FieldMapping
+	scope        *scopedLogger
+}
+
+// scopeBuffer holds the entries of a scope below a trigger level in a ring
+// buffer until an entry at the trigger level is written
+type scopeBuffer struct {
+	level   LogLevel
+	trigger LogLevel
+
+	mutex     sync.Mutex
+	entries   []bufferedEntry
+	next      int
+	full      bool
+	triggered bool
+}
+
+// FingersCrossed returns a scoped Logger based on logger holding back entries
+// from level up to, but not including, trigger in a ring buffer of size
+// entries. The entries are written once an entry at trigger or above is
+// written through the scope or any scope created from it. From then on the
+// scope writes all entries at level and above directly. Entries below level
+// are handled as usual.
+//
+// This gives the full debug context for e.g. failed requests without writing
+// it for successful ones. logger is returned as is if it isn't a logger of
+// this package
+func FingersCrossed(logger Logger, level, trigger LogLevel, size int) Logger {
+	scope, ok := logger.WithScope(nil).(*scopedLogger)
+	if !ok {
+		return logger
+	}
+
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+
+	scope.buffer = &scopeBuffer{
+		level:   level,
+		trigger: trigger,
+		entries: make([]bufferedEntry, size),
+	}
+	return scope
+}
+
+// write handles an entry written through a scope using the buffer. It
+// returns true if the entry was handled and false if it should be
+// written as usual
+func (b *scopeBuffer) write(l *logger, logLevel LogLevel, msg string, now time.Time, fields Fields, typed []Field, fieldMapping FieldMapping, scope *scopedLogger) bool {
+	if logLevel.severity() < b.level.severity() {
+		return false
+	}
+
+	b.mutex.Lock()
+
+	if b.triggered {
+		b.mutex.Unlock()
+
+		// Entries below the level of the logger are written directly
+		if logLevel.severity() < l.logLevel.severity() {
+			l.emit(logLevel, msg, now, fields, typed, fieldMapping, scope)
+			return true
+		}
+		return false
+	}
+
+	if logLevel.severity() < b.trigger.severity() {
+		b.entries[b.next] = bufferedEntry{
+			logLevel:     logLevel,
+			msg:          msg,
+			timestamp:    now,
+			fields:       fields,
+			typed:        typed,
+			fieldMapping: fieldMapping,
+			scope:        scope,
+		}
+		b.next = (b.next + 1) % len(b.entries)
+		if b.next == 0 {
+			b.full = true
+		}
+		b.mutex.Unlock()
+		return true
+	}
+
+	// Triggered. Write the held entries, oldest first, before the trigger entry
+	held := b.take()
+	b.triggered = true
+	b.mutex.Unlock()
+
+	for _, e := range held {
+		l.emit(e.logLevel, e.msg, e.timestamp, e.fields, e.typed, e.fieldMapping, e.scope)
+	}
+
+	return false
+}
+
+// take returns the held entries, oldest first, and releases them
+func (b *scopeBuffer) take() []bufferedEntry {
+	var held []bufferedEntry
+	if b.full {
+		held = append(held, b.entries[b.next:]...)
+	}
+	held = append(held, b.entries[:b.next]...)
+
+	b.entries = nil
+	b.next = 0
+	b.full = false
+
+	return held
+}
@@ -0,0 +1,42 @@
+package wlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFingersCrossed(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+
+	// A successful request writes nothing below the trigger level
+	ok := FingersCrossed(logger, Dbg, Err, 10)
+	ok.Debug("parsing request")
+	ok.Info("request handled")
+	if w.Len() != 0 {
+		t.Fatalf("expected entries to be held back, got %q", w.String())
+	}
+
+	failed := FingersCrossed(logger, Dbg, Err, 2)
+	failed.Trace("below the buffer level")
+	failed.Debug("dropped from the buffer")
+	failed.Debug("parsing request")
+	failed.With(String("step", "store")).Info("storing")
+	failed.Error("request failed")
+	failed.Debug("written directly")
+
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	want := []string{"parsing request", "storing", "request failed", "written directly"}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %q", len(want), lines)
+	}
+	for i := range want {
+		if !strings.Contains(lines[i], want[i]) {
+			t.Errorf("expected %q in line %d, got %q", want[i], i, lines[i])
+		}
+	}
+	if !strings.Contains(lines[1], "step: store") {
+		t.Errorf("expected the fields of the child scope, got %q", lines[1])
+	}
+}
@@ -0,0 +1,123 @@
+package wlog
+
+import (
+	"context"
+	"sync"
+)
+
+// loggerKey is the context key used to attach a Logger to a context.Context
+type loggerKey struct{}
+
+// contextField maps a context key to the name of the field
+// its value is written as
+type contextField struct {
+	key   interface{}
+	field string
+}
+
+var (
+	contextFieldsMutex sync.RWMutex
+	contextFieldList   []contextField
+)
+
+// RegisterContextKey registers a context key whose value, when present in a
+// context.Context, is added as a field named field to entries logged with the
+// *Ctx methods or loggers created with WithContext. Registering a key again
+// replaces its field name
+func RegisterContextKey(key interface{}, field string) {
+	contextFieldsMutex.Lock()
+	defer contextFieldsMutex.Unlock()
+
+	for i := range contextFieldList {
+		if contextFieldList[i].key == key {
+			contextFieldList[i].field = field
+			return
+		}
+	}
+
+	contextFieldList = append(contextFieldList, contextField{key, field})
+}
+
+// UnregisterContextKey removes a context key registered with RegisterContextKey
+func UnregisterContextKey(key interface{}) {
+	contextFieldsMutex.Lock()
+	defer contextFieldsMutex.Unlock()
+
+	for i := range contextFieldList {
+		if contextFieldList[i].key == key {
+			contextFieldList = append(contextFieldList[:i:i], contextFieldList[i+1:]...)
+			return
+		}
+	}
+}
+
+// contextFields returns a new slice holding typed followed by a field for
+// each registered context key present in ctx and the fields of the trace
+// context carried by ctx, if any. Fields already in typed are not added again
+func contextFields(ctx context.Context, typed []Field, fieldMapping FieldMapping) []Field {
+	if ctx == nil {
+		return typed
+	}
+
+	contextFieldsMutex.RLock()
+	defer contextFieldsMutex.RUnlock()
+
+	fields := make([]Field, len(typed), len(typed)+len(contextFieldList)+3)
+	copy(fields, typed)
+
+	for _, cf := range contextFieldList {
+		if v := ctx.Value(cf.key); v != nil && !hasField(typed, cf.field) {
+			fields = append(fields, Any(cf.field, v))
+		}
+	}
+
+	if tc, ok := TraceContextFromContext(ctx); ok && !hasField(typed, mapKey(TraceIDField, fieldMapping)) {
+		fields = tc.appendFields(fields, fieldMapping)
+	}
+
+	return fields
+}
+
+// NewContext returns a copy of ctx carrying logger. Use FromContext
+// to retrieve it
+func NewContext(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the Logger carried by ctx. The default logger
+// is returned if ctx doesn't carry a Logger
+func FromContext(ctx context.Context) Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(Logger); ok {
+			return logger
+		}
+	}
+	return defaultLogger
+}
+
+// WithContext returns a new instance of Logger based on the Logger
+// carried by ctx, or the default logger, with the values of any
+// registered context keys and trace context added as fields
+func WithContext(ctx context.Context) Logger {
+	return FromContext(ctx).WithContext(ctx)
+}
+
+// DebugCtx logs a debug message using the Logger carried by ctx
+func DebugCtx(ctx context.Context, v ...interface{}) {
+	FromContext(ctx).DebugCtx(ctx, v...)
+}
+
+// InfoCtx logs an informal message using the Logger carried by ctx
+func InfoCtx(ctx context.Context, v ...interface{}) {
+	FromContext(ctx).InfoCtx(ctx, v...)
+}
+
+// WarningCtx logs a warning message using the Logger carried by ctx
+func WarningCtx(ctx context.Context, v ...interface{}) {
+	FromContext(ctx).WarningCtx(ctx, v...)
+}
+
+// ErrorCtx logs an error message using the Logger carried by ctx
+func ErrorCtx(ctx context.Context, v ...interface{}) {
+	FromContext(ctx).ErrorCtx(ctx, v...)
+}
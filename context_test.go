@@ -0,0 +1,56 @@
+package wlog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+type testContextKey string
+
+func TestContextFields(t *testing.T) {
+	RegisterContextKey(testContextKey("requestId"), "request_id")
+	RegisterContextKey(testContextKey("tenant"), "tenant_id")
+	defer UnregisterContextKey(testContextKey("requestId"))
+	defer UnregisterContextKey(testContextKey("tenant"))
+
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+
+	ctx := context.WithValue(context.Background(), testContextKey("requestId"), "abc123")
+	ctx = context.WithValue(ctx, testContextKey("tenant"), 7)
+	ctx = NewContext(ctx, logger.WithScope(Fields{"scope": "request"}))
+
+	InfoCtx(ctx, "from context")
+
+	got := w.String()
+	for _, want := range []string{"from context", "scope: request", "request_id: abc123", "tenant_id: 7"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output %q should contain %q", got, want)
+		}
+	}
+
+	w.Reset()
+	DebugCtx(ctx, "filtered")
+	if w.Len() > 0 {
+		t.Errorf("unexpected log output %q", w.String())
+	}
+
+	w.Reset()
+	logger.WithContext(ctx).Warning("with context")
+	if got := w.String(); !strings.Contains(got, "request_id: abc123") || strings.Contains(got, "scope: request") {
+		t.Errorf("unexpected log output %q", got)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if FromContext(context.Background()) != DefaultLogger() {
+		t.Errorf("FromContext() should return the default logger when none is attached")
+	}
+
+	logger := New(nil, Nfo, false)
+	if FromContext(NewContext(context.Background(), logger)) != logger {
+		t.Errorf("FromContext() should return the attached logger")
+	}
+}
@@ -0,0 +1,206 @@
+package wlog
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RepeatCountField is the name of the field holding the number of times
+// an entry was repeated in the entries written for collapsed duplicates
+const RepeatCountField = "repeat_count"
+
+// repeatedEntry is an entry that may be repeated
+type repeatedEntry struct {
+	logLevel     LogLevel
+	msg          string
+	fields       Fields
+	typed        []Field
+	fieldMapping FieldMapping
+	scope        *scopedLogger
+}
+
+func (e *repeatedEntry) sameAs(other *repeatedEntry) bool {
+	return e.logLevel == other.logLevel && e.msg == other.msg &&
+		sameFields(e.fields, other.fields) && sameTyped(e.typed, other.typed)
+}
+
+// deduper collapses consecutive duplicate entries written within a window
+type deduper struct {
+	window time.Duration
+
+	// expired, if set, is called with the repeated entry and the
+	// number of repeats when the window of a series has passed
+	expired func(entry *repeatedEntry, repeats int)
+
+	mutex   sync.Mutex
+	last    *repeatedEntry
+	start   time.Time
+	repeats int
+	timer   *time.Timer
+}
+
+func newDeduper(window time.Duration) *deduper {
+	return &deduper{window: window}
+}
+
+// sameFields reports whether two field maps hold the same values
+func sameFields(a, b Fields) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if len(a) == 0 || reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer() {
+		return true
+	}
+	for k, v := range a {
+		w, ok := b[k]
+		if !ok || !sameValue(v, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameTyped reports whether two slices of typed fields hold the same fields
+func sameTyped(a, b []Field) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Key != b[i].Key || a[i].Type != b[i].Type || a[i].integer != b[i].integer ||
+			a[i].str != b[i].str || !sameValue(a[i].iface, b[i].iface) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameValue reports whether two field values are the same without
+// formatting them. Maps, slices and pointers are the same if they refer
+// to the same data. Functions, including lazy values, are never the same
+// since their results can't be compared without calling them
+func sameValue(a, b interface{}) (same bool) {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	t := reflect.TypeOf(a)
+	if t != reflect.TypeOf(b) {
+		return false
+	}
+
+	switch t.Kind() {
+	case reflect.Func:
+		return false
+	case reflect.Map, reflect.Chan, reflect.Ptr, reflect.UnsafePointer:
+		return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+	case reflect.Slice:
+		va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+		return va.Pointer() == vb.Pointer() && va.Len() == vb.Len()
+	}
+
+	if !t.Comparable() {
+		return false
+	}
+
+	// Comparable types may still hold values that aren't, e.g. a
+	// slice in an interface field of a struct
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a == b
+}
+
+// check reports whether entry should be written. If it ends a series of
+// duplicates, the previous entry is returned with the number of repeats
+func (d *deduper) check(entry *repeatedEntry, now time.Time) (bool, *repeatedEntry, int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.last != nil && d.last.sameAs(entry) && now.Sub(d.start) < d.window {
+		d.repeats++
+
+		// Report the repeats when the window has passed, unless
+		// the series is ended by another entry before that
+		if d.repeats == 1 && d.expired != nil {
+			series := d.last
+			d.timer = time.AfterFunc(d.start.Add(d.window).Sub(now), func() {
+				d.expire(series)
+			})
+		}
+		return false, nil, 0
+	}
+
+	previous, repeats := d.take()
+	d.last = entry
+	d.start = now
+
+	return true, previous, repeats
+}
+
+// flush ends the current series of duplicates, returning the
+// repeated entry and the number of repeats, if any
+func (d *deduper) flush() (*repeatedEntry, int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	previous, repeats := d.take()
+	d.last = nil
+
+	return previous, repeats
+}
+
+// expire ends series if it's still the current series
+func (d *deduper) expire(series *repeatedEntry) {
+	d.mutex.Lock()
+	if d.last != series {
+		d.mutex.Unlock()
+		return
+	}
+	previous, repeats := d.take()
+	d.last = nil
+	d.mutex.Unlock()
+
+	if previous != nil {
+		d.expired(previous, repeats)
+	}
+}
+
+func (d *deduper) take() (*repeatedEntry, int) {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+
+	repeats := d.repeats
+	d.repeats = 0
+	if repeats == 0 {
+		return nil, 0
+	}
+	return d.last, repeats
+}
+
+// writeRepeated writes the entry reporting that entry was repeated
+func (l *logger) writeRepeated(entry *repeatedEntry, repeats int, now time.Time) {
+	typed := make([]Field, 0, len(entry.typed)+1)
+	typed = append(typed, entry.typed...)
+	typed = append(typed, Int(RepeatCountField, repeats))
+
+	msg := "last message repeated " + strconv.Itoa(repeats) + " times"
+	if repeats == 1 {
+		msg = "last message repeated once"
+	}
+	l.emit(entry.logLevel, msg, now, entry.fields, typed, entry.fieldMapping, entry.scope)
+}
+
+// flushRepeated writes the entry reporting repeats of the last entry, if any
+func (l *logger) flushRepeated() {
+	if deduper, _ := l.deduper.Load().(*deduper); deduper != nil {
+		if entry, repeats := deduper.flush(); entry != nil {
+			l.writeRepeated(entry, repeats, time.Now())
+		}
+	}
+}
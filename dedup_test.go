@@ -0,0 +1,155 @@
+package wlog
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+	logger.SetDedupWindow(time.Minute)
+
+	scope := logger.WithScope(Fields{"tenant": "acme"})
+	for i := 0; i < 4; i++ {
+		scope.Warning("disk almost full")
+	}
+	scope.With(Int("attempt", 2)).Warning("disk almost full")
+	logger.Info("done")
+	logger.Info("done")
+
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines, got %q", lines)
+	}
+	for i, want := range []string{"disk almost full", "last message repeated 3 times", "attempt: 2", "done"} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("expected %q in line %d, got %q", want, i, lines[i])
+		}
+	}
+	if !strings.Contains(lines[1], "tenant: acme") || !strings.Contains(lines[1], "repeat_count: 3") {
+		t.Errorf("unexpected repeat entry %q", lines[1])
+	}
+
+	// Pending repeats are written when the window is changed
+	logger.SetDedupWindow(0)
+	if !strings.Contains(w.String(), "last message repeated once") {
+		t.Errorf("expected the pending repeat entry, got %q", w.String())
+	}
+
+	if stats := logger.Stats(); stats.Dropped != 4 {
+		t.Errorf("expected 4 dropped entries, got %d", stats.Dropped)
+	}
+}
+
+func TestDedupJSON(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+	logger.SetFormatter(JSONFormatter{})
+	logger.SetDedupWindow(time.Minute)
+
+	logger.Error("failed")
+	logger.Error("failed")
+	logger.Error("failed")
+	logger.Info("done")
+
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], `"repeat_count":2`) {
+		t.Errorf("unexpected output %q", lines)
+	}
+}
+
+func TestDedupWindow(t *testing.T) {
+	d := newDeduper(time.Second)
+	start := time.Now()
+	entry := &repeatedEntry{logLevel: Wrn, msg: "retrying"}
+
+	if write, _, _ := d.check(entry, start); !write {
+		t.Error("expected the first entry to be written")
+	}
+	if write, _, _ := d.check(entry, start.Add(time.Millisecond)); write {
+		t.Error("expected a duplicate within the window to be collapsed")
+	}
+	write, previous, repeats := d.check(entry, start.Add(time.Second))
+	if !write || previous == nil || repeats != 1 {
+		t.Errorf("expected a duplicate after the window to end the series, got %v %v %d", write, previous, repeats)
+	}
+}
+
+func TestDedupLazy(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+	logger.SetDedupWindow(time.Minute)
+
+	// Entries with lazy values are never collapsed, their values may differ
+	calls := 0
+	for i := 0; i < 3; i++ {
+		i := i
+		logger.With(Lazy("i", func() interface{} {
+			calls++
+			return i
+		})).Info("processed")
+	}
+	if calls != 3 {
+		t.Errorf("expected the lazy value to be computed once per written entry, got %d calls", calls)
+	}
+	for i := 0; i < 3; i++ {
+		if want := "processed [i: " + strconv.Itoa(i) + "]"; !strings.Contains(w.String(), want) {
+			t.Errorf("expected %q in %q", want, w.String())
+		}
+	}
+
+	// Other values of the same type are not duplicates
+	w.Reset()
+	logger.With(String("id", "1"), Any("tags", []string{"a"})).Info("hello")
+	logger.With(String("id", "2"), Any("tags", []string{"a"})).Info("hello")
+	if n := strings.Count(w.String(), "\n"); n != 2 {
+		t.Errorf("expected 2 lines, got %q", w.String())
+	}
+}
+
+func TestDedupWindowExpiry(t *testing.T) {
+	w := &syncBuffer{}
+	logger := New(w, Nfo, false)
+	logger.SetDedupWindow(20 * time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		logger.Info("x")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(w.String(), "last message repeated 2 times") {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the repeat entry once the window passed, got %q", w.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// A new series starts once the window has passed
+	logger.Info("x")
+	if n := strings.Count(w.String(), "\n"); n != 3 {
+		t.Errorf("expected 3 lines, got %q", w.String())
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
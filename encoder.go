@@ -0,0 +1,221 @@
+package wlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// textEncoder implements FieldEncoder for TextFormatter. Fields
+// are written as comma separated "key: value" pairs
+type textEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *textEncoder) addKey(key string) {
+	if e.count > 0 {
+		writeString(e.w, ", ")
+	}
+	e.count++
+	writeString(e.w, key)
+	writeString(e.w, ": ")
+}
+
+func (e *textEncoder) AddString(key, value string) {
+	e.addKey(key)
+	writeString(e.w, value)
+}
+
+func (e *textEncoder) AddInt64(key string, value int64) {
+	e.addKey(key)
+	writeString(e.w, strconv.FormatInt(value, 10))
+}
+
+func (e *textEncoder) AddFloat64(key string, value float64) {
+	e.addKey(key)
+	writeString(e.w, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (e *textEncoder) AddBool(key string, value bool) {
+	e.addKey(key)
+	writeString(e.w, strconv.FormatBool(value))
+}
+
+func (e *textEncoder) AddDuration(key string, value time.Duration) {
+	e.addKey(key)
+	writeString(e.w, value.String())
+}
+
+func (e *textEncoder) AddTime(key string, value time.Time) {
+	e.addKey(key)
+	writeString(e.w, value.Format(time.RFC3339Nano))
+}
+
+func (e *textEncoder) AddAny(key string, value interface{}) {
+	switch v := value.(type) {
+	case LazyValue:
+		e.AddAny(key, v())
+		return
+	case LogMarshaler:
+		e.AddObject(key, v)
+		return
+	}
+	e.addKey(key)
+	writeString(e.w, fmt.Sprintf("%v", value))
+}
+
+func (e *textEncoder) AddObject(key string, value LogMarshaler) {
+	e.addKey(key)
+	writeString(e.w, "{")
+	value.MarshalLog(&textEncoder{w: e.w})
+	writeString(e.w, "}")
+}
+
+// jsonEncoder implements FieldEncoder for JSONFormatter. Fields
+// are written as comma separated "key":value members of an object.
+// The first error, if any, encountered while marshaling a value
+// is kept in err
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+	err   error
+}
+
+func (e *jsonEncoder) addKey(key string) {
+	if e.count > 0 {
+		writeString(e.w, ",")
+	}
+	e.count++
+	writeJSONString(e.w, key)
+	writeString(e.w, ":")
+}
+
+func (e *jsonEncoder) AddString(key, value string) {
+	e.addKey(key)
+	writeJSONString(e.w, value)
+}
+
+func (e *jsonEncoder) AddInt64(key string, value int64) {
+	e.addKey(key)
+	writeString(e.w, strconv.FormatInt(value, 10))
+}
+
+func (e *jsonEncoder) AddFloat64(key string, value float64) {
+	e.addKey(key)
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		// Not representable as a JSON number
+		writeJSONString(e.w, strconv.FormatFloat(value, 'g', -1, 64))
+		return
+	}
+	writeString(e.w, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (e *jsonEncoder) AddBool(key string, value bool) {
+	e.addKey(key)
+	writeString(e.w, strconv.FormatBool(value))
+}
+
+func (e *jsonEncoder) AddDuration(key string, value time.Duration) {
+	e.addKey(key)
+	writeJSONString(e.w, value.String())
+}
+
+func (e *jsonEncoder) AddTime(key string, value time.Time) {
+	e.addKey(key)
+	writeJSONString(e.w, value.Format(time.RFC3339Nano))
+}
+
+func (e *jsonEncoder) AddAny(key string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		e.AddString(key, v)
+		return
+	case LogMarshaler:
+		e.AddObject(key, v)
+		return
+	case LazyValue:
+		e.AddAny(key, v())
+		return
+	}
+
+	e.addKey(key)
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		if e.err == nil {
+			e.err = err
+		}
+		// Keep the entry valid JSON
+		writeJSONString(e.w, fmt.Sprintf("%v", value))
+		return
+	}
+
+	if _, err := e.w.Write(b); err != nil && e.err == nil {
+		e.err = err
+	}
+}
+
+func (e *jsonEncoder) AddObject(key string, value LogMarshaler) {
+	e.addKey(key)
+	writeString(e.w, "{")
+	nested := &jsonEncoder{w: e.w}
+	value.MarshalLog(nested)
+	writeString(e.w, "}")
+	if nested.err != nil && e.err == nil {
+		e.err = nested.err
+	}
+}
+
+const hex = "0123456789abcdef"
+
+// writeJSONString writes s as a quoted JSON string using the same
+// escaping rules as encoding/json, including HTML escaping
+func writeJSONString(w io.Writer, s string) {
+	buf := make([]byte, 0, len(s)+2)
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			switch {
+			case b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&':
+				buf = append(buf, b)
+			case b == '"' || b == '\\':
+				buf = append(buf, '\\', b)
+			case b == '\n':
+				buf = append(buf, '\\', 'n')
+			case b == '\r':
+				buf = append(buf, '\\', 'r')
+			case b == '\t':
+				buf = append(buf, '\\', 't')
+			case b == '\b':
+				buf = append(buf, '\\', 'b')
+			case b == '\f':
+				buf = append(buf, '\\', 'f')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			buf = append(buf, "\ufffd"...)
+		case r == '\u2028' || r == '\u2029':
+			buf = append(buf, '\\', 'u', '2', '0', '2', hex[r&0xF])
+		default:
+			buf = append(buf, s[i:i+size]...)
+		}
+		i += size
+	}
+	buf = append(buf, '"')
+
+	if _, err := w.Write(buf); err != nil {
+		fmt.Fprintf(os.Stderr, "could not write entry log, err: %s", err)
+	}
+}
@@ -0,0 +1,350 @@
+package wlog
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// FieldType tells a formatter how to interpret the value of a Field
+type FieldType uint8
+
+// The field types available
+const (
+	// SkipType is used for fields that should not be written, e.g. a nil error
+	SkipType FieldType = iota
+	StringType
+	IntType
+	FloatType
+	BoolType
+	DurationType
+	TimeType
+	ErrorType
+	AnyType
+	ObjectType
+	LazyType
+)
+
+// Field is a strongly typed key-value pair. Fields are created with
+// the constructors in this file (String, Int, Duration, ...) and can be
+// encoded by formatters without reflection
+type Field struct {
+	Key     string
+	Type    FieldType
+	integer int64
+	str     string
+	iface   interface{}
+}
+
+// String constructs a field holding a string
+func String(key, value string) Field {
+	return Field{Key: key, Type: StringType, str: value}
+}
+
+// Int constructs a field holding an int
+func Int(key string, value int) Field {
+	return Int64(key, int64(value))
+}
+
+// Int64 constructs a field holding an int64
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Type: IntType, integer: value}
+}
+
+// Float64 constructs a field holding a float64
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Type: FloatType, integer: int64(math.Float64bits(value))}
+}
+
+// Bool constructs a field holding a bool
+func Bool(key string, value bool) Field {
+	var i int64
+	if value {
+		i = 1
+	}
+	return Field{Key: key, Type: BoolType, integer: i}
+}
+
+// Duration constructs a field holding a time.Duration
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Type: DurationType, integer: int64(value)}
+}
+
+// Time constructs a field holding a time.Time. Times that can be represented
+// as nanoseconds since the epoch are stored without allocating
+func Time(key string, value time.Time) Field {
+	if y := value.Year(); y < 1678 || y > 2261 {
+		// Outside the range of UnixNano. Keep the value as is
+		return Field{Key: key, Type: TimeType, iface: value}
+	}
+	return Field{Key: key, Type: TimeType, integer: value.UnixNano(), iface: value.Location()}
+}
+
+// ErrorField constructs a field with the key "error" holding the
+// message of err. A nil error results in a field that is not written
+func ErrorField(err error) Field {
+	return NamedError("error", err)
+}
+
+// NamedError constructs a field holding the message of err. A nil
+// error results in a field that is not written
+func NamedError(key string, err error) Field {
+	if err == nil {
+		return Field{Key: key, Type: SkipType}
+	}
+	return Field{Key: key, Type: ErrorType, iface: err}
+}
+
+// Object constructs a field holding a LogMarshaler. The value is
+// written as a nested object
+func Object(key string, value LogMarshaler) Field {
+	return Field{Key: key, Type: ObjectType, iface: value}
+}
+
+// LazyValue is a value that is computed only when an entry is
+// written. It can be used in Fields or with the Lazy constructor
+// for values that are expensive to compute, e.g. debug dumps
+type LazyValue func() interface{}
+
+// Lazy constructs a field whose value is computed by fn when the entry
+// is written. fn is not called if the entry is filtered out by log level
+func Lazy(key string, fn func() interface{}) Field {
+	return Field{Key: key, Type: LazyType, iface: LazyValue(fn)}
+}
+
+// Any constructs a field holding an arbitrary value. Values of the types
+// supported by the other constructors are stored using those
+func Any(key string, value interface{}) Field {
+	switch v := value.(type) {
+	case string:
+		return String(key, v)
+	case int:
+		return Int(key, v)
+	case int64:
+		return Int64(key, v)
+	case float64:
+		return Float64(key, v)
+	case bool:
+		return Bool(key, v)
+	case time.Duration:
+		return Duration(key, v)
+	case time.Time:
+		return Time(key, v)
+	case LogMarshaler:
+		return Object(key, v)
+	case LazyValue:
+		return Field{Key: key, Type: LazyType, iface: v}
+	case error:
+		return NamedError(key, v)
+	}
+	return Field{Key: key, Type: AnyType, iface: value}
+}
+
+// Value returns the value of the field as an interface{}
+func (f Field) Value() interface{} {
+	switch f.Type {
+	case StringType:
+		return f.str
+	case IntType:
+		return f.integer
+	case FloatType:
+		return math.Float64frombits(uint64(f.integer))
+	case BoolType:
+		return f.integer == 1
+	case DurationType:
+		return time.Duration(f.integer)
+	case TimeType:
+		return f.time()
+	case ErrorType:
+		return errorString(f.iface.(error))
+	case AnyType:
+		return f.iface
+	case ObjectType:
+		return marshalFields(f.iface.(LogMarshaler))
+	case LazyType:
+		return resolveValue(f.iface)
+	}
+	return nil
+}
+
+// errorString returns the message of err. A panic in Error, e.g. for a
+// nil pointer held by the error interface, results in "<nil>" if err
+// is a nil pointer and a description of the panic otherwise
+func errorString(err error) (msg string) {
+	defer func() {
+		if v := recover(); v != nil {
+			if rv := reflect.ValueOf(err); rv.Kind() == reflect.Ptr && rv.IsNil() {
+				msg = "<nil>"
+				return
+			}
+			msg = fmt.Sprintf("<panic in Error: %v>", v)
+		}
+	}()
+	return err.Error()
+}
+
+// AddTo adds the field to a FieldEncoder
+func (f Field) AddTo(enc FieldEncoder) {
+	switch f.Type {
+	case StringType:
+		enc.AddString(f.Key, f.str)
+	case IntType:
+		enc.AddInt64(f.Key, f.integer)
+	case FloatType:
+		enc.AddFloat64(f.Key, math.Float64frombits(uint64(f.integer)))
+	case BoolType:
+		enc.AddBool(f.Key, f.integer == 1)
+	case DurationType:
+		enc.AddDuration(f.Key, time.Duration(f.integer))
+	case TimeType:
+		enc.AddTime(f.Key, f.time())
+	case ErrorType:
+		enc.AddString(f.Key, errorString(f.iface.(error)))
+	case AnyType:
+		enc.AddAny(f.Key, f.iface)
+	case ObjectType:
+		enc.AddObject(f.Key, f.iface.(LogMarshaler))
+	case LazyType:
+		Any(f.Key, resolveValue(f.iface)).AddTo(enc)
+	}
+}
+
+func (f Field) time() time.Time {
+	if t, ok := f.iface.(time.Time); ok {
+		return t
+	}
+	t := time.Unix(0, f.integer)
+	if loc, ok := f.iface.(*time.Location); ok {
+		t = t.In(loc)
+	}
+	return t
+}
+
+// FieldEncoder is used by formatters to encode fields without
+// resorting to reflection for the common types
+type FieldEncoder interface {
+	AddString(key, value string)
+	AddInt64(key string, value int64)
+	AddFloat64(key string, value float64)
+	AddBool(key string, value bool)
+	AddDuration(key string, value time.Duration)
+	AddTime(key string, value time.Time)
+	AddAny(key string, value interface{})
+	AddObject(key string, value LogMarshaler)
+}
+
+// LogMarshaler is implemented by types that control how they are
+// written to the log. MarshalLog adds the members that should be
+// logged to enc, which makes it possible to leave out sensitive ones
+type LogMarshaler interface {
+	MarshalLog(enc FieldEncoder)
+}
+
+// MarshalLog implements LogMarshaler, making Fields nested in
+// other fields render as objects. Keys are added in sorted order
+func (f Fields) MarshalLog(enc FieldEncoder) {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		enc.AddAny(k, f[k])
+	}
+}
+
+// mapEncoder implements FieldEncoder by collecting the
+// values in a Fields map
+type mapEncoder Fields
+
+func (m mapEncoder) AddString(key, value string)                 { m[key] = value }
+func (m mapEncoder) AddInt64(key string, value int64)            { m[key] = value }
+func (m mapEncoder) AddFloat64(key string, value float64)        { m[key] = value }
+func (m mapEncoder) AddBool(key string, value bool)              { m[key] = value }
+func (m mapEncoder) AddDuration(key string, value time.Duration) { m[key] = value }
+func (m mapEncoder) AddTime(key string, value time.Time)         { m[key] = value }
+func (m mapEncoder) AddAny(key string, value interface{})        { m[key] = value }
+func (m mapEncoder) AddObject(key string, value LogMarshaler)    { m[key] = marshalFields(value) }
+
+// marshalFields returns the members added by a LogMarshaler as Fields
+func marshalFields(value LogMarshaler) Fields {
+	fields := Fields{}
+	value.MarshalLog(mapEncoder(fields))
+	return fields
+}
+
+// resolveValue computes the value of a LazyValue. Any other
+// value is returned as is
+func resolveValue(value interface{}) interface{} {
+	if lazy, ok := value.(LazyValue); ok {
+		return resolveValue(lazy())
+	}
+	return value
+}
+
+// resolveFields returns fields with any LazyValue computed. The
+// map is only copied if it contains lazy values
+func resolveFields(fields Fields) Fields {
+	var resolved Fields
+	for k, v := range fields {
+		if _, ok := v.(LazyValue); ok {
+			if resolved == nil {
+				resolved = make(Fields, len(fields))
+				for k, v := range fields {
+					resolved[k] = v
+				}
+			}
+			resolved[k] = resolveValue(v)
+		}
+	}
+	if resolved == nil {
+		return fields
+	}
+	return resolved
+}
+
+// resolveTyped returns typed with the values of lazy fields computed.
+// The slice is only copied if there are lazy fields
+func resolveTyped(typed []Field) []Field {
+	var resolved []Field
+	for i, f := range typed {
+		if f.Type == LazyType {
+			if resolved == nil {
+				resolved = append(make([]Field, 0, len(typed)), typed...)
+			}
+			resolved[i] = Any(f.Key, resolveValue(f.iface))
+		}
+	}
+	if resolved == nil {
+		return typed
+	}
+	return resolved
+}
+
+// mergeFields returns a copy of fields with the typed fields added. It is
+// used for formatters that don't implement FieldFormatter
+func mergeFields(fields Fields, typed []Field) Fields {
+	merged := make(Fields, len(fields)+len(typed))
+	for k, v := range fields {
+		merged[k] = resolveValue(v)
+	}
+	for _, f := range typed {
+		if f.Type != SkipType {
+			merged[f.Key] = f.Value()
+		}
+	}
+	return merged
+}
+
+// hasField reports whether key is present in typed
+func hasField(typed []Field, key string) bool {
+	for i := range typed {
+		if typed[i].Key == key && typed[i].Type != SkipType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package wlog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFieldValue(t *testing.T) {
+	now := time.Date(2020, 1, 23, 9, 57, 54, 157141000, time.UTC)
+
+	tests := []struct {
+		field Field
+		want  interface{}
+	}{
+		{String("k", "v"), "v"},
+		{Int("k", 42), int64(42)},
+		{Float64("k", 1.5), 1.5},
+		{Bool("k", true), true},
+		{Duration("k", time.Second), time.Second},
+		{Time("k", now), now},
+		{Time("k", time.Time{}), time.Time{}},
+		{ErrorField(errors.New("failed")), "failed"},
+		{ErrorField(nil), nil},
+		{ErrorField((*testError)(nil)), "<nil>"},
+		{Any("k", []int{1}), []int{1}},
+	}
+	for _, tt := range tests {
+		got := tt.field.Value()
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("Field.Value() = %v, want %v", got, tt.want)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := (JSONFormatter{}).FormatFields(buf, Err, "failed", now, nil, []Field{ErrorField((*testError)(nil))}, nil); err != nil || !strings.Contains(buf.String(), `"error":"\u003cnil\u003e"`) {
+		t.Errorf("unexpected output for a nil error pointer %q, %v", buf.String(), err)
+	}
+
+	if f := Any("k", 42); f.Type != IntType {
+		t.Errorf("Any() should use the typed constructor, got type %d", f.Type)
+	}
+}
+
+type testError struct {
+	msg string
+}
+
+func (e *testError) Error() string {
+	return e.msg
+}
+
+func TestTypedFields(t *testing.T) {
+	now := time.Date(2020, 1, 23, 9, 57, 54, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		formatter Formatter
+		want      []string
+	}{
+		{
+			"Text",
+			TextFormatter{},
+			[]string{"scope: root", "name: user", "count: 3", "elapsed: 1.5s", "ok: true", "at: 2020-01-23T09:57:54Z", "error: failed"},
+		},
+		{
+			"JSON",
+			JSONFormatter{},
+			[]string{`"scope":"root"`, `"name":"user","count":3,"elapsed":"1.5s","ok":true,"at":"2020-01-23T09:57:54Z","error":"failed"}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			logger := New(w, Nfo, false)
+			logger.SetFormatter(tt.formatter)
+			logger.SetFields(Fields{"scope": "root"})
+
+			scope := logger.With(String("name", "user"), Int("count", 3))
+			scope = scope.With(Duration("elapsed", 1500*time.Millisecond), Bool("ok", true), Time("at", now), ErrorField(errors.New("failed")), ErrorField(nil))
+			scope.Info("typed")
+
+			got := w.String()
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("output %q should contain %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTypedFieldOverridesField(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+	logger.SetFormatter(JSONFormatter{})
+
+	logger.WithScope(Fields{"user": "map"}).With(String("user", "typed")).Info("override")
+
+	if got := w.String(); strings.Count(got, `"user"`) != 1 || !strings.Contains(got, `"user":"typed"`) {
+		t.Errorf("typed field should override the map field, got %q", got)
+	}
+}
+
+type plainFormatter struct{}
+
+func (plainFormatter) Format(w io.Writer, logLevel LogLevel, msg string, timestamp time.Time, fields Fields, fieldMapping FieldMapping) error {
+	_, err := fmt.Fprintf(w, "%s %v\n", msg, fields["count"])
+	return err
+}
+
+func TestTypedFieldsPlainFormatter(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+	logger.SetFormatter(plainFormatter{})
+
+	logger.With(Int("count", 7)).Info("merged")
+
+	if got := w.String(); got != "merged 7\n" {
+		t.Errorf("got %q, want %q", got, "merged 7\n")
+	}
+}
+
+func BenchmarkTypedFields(b *testing.B) {
+	logger := New(nil, Nfo, false)
+	logger.SetFormatter(JSONFormatter{})
+	scope := logger.With(String("name", "user"), Int("count", 3), Duration("elapsed", time.Second))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		scope.Info("benchmark")
+	}
+}
+
+type testUser struct {
+	id       int
+	name     string
+	password string
+}
+
+func (u testUser) MarshalLog(enc FieldEncoder) {
+	enc.AddInt64("id", int64(u.id))
+	enc.AddString("name", u.name)
+}
+
+func TestLogMarshaler(t *testing.T) {
+	user := testUser{id: 1, name: "john", password: "secret"}
+
+	tests := []struct {
+		name      string
+		formatter Formatter
+		want      string
+	}{
+		{"Text", TextFormatter{}, "user: {id: 1, name: john}"},
+		{"JSON", JSONFormatter{}, `"user":{"id":1,"name":"john"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, scope := range []func(Logger) Logger{
+				func(l Logger) Logger { return l.With(Object("user", user)) },
+				func(l Logger) Logger { return l.WithScope(Fields{"user": user}) },
+			} {
+				w := &bytes.Buffer{}
+				logger := New(w, Nfo, false)
+				logger.SetFormatter(tt.formatter)
+
+				scope(logger).Info("marshaler")
+
+				got := w.String()
+				if !strings.Contains(got, tt.want) {
+					t.Errorf("output %q should contain %q", got, tt.want)
+				}
+				if strings.Contains(got, user.password) {
+					t.Errorf("output %q should not contain the password", got)
+				}
+			}
+		})
+	}
+
+	if got := fmt.Sprint(Object("user", user).Value()); got != "map[id:1 name:john]" {
+		t.Errorf("Field.Value() = %s", got)
+	}
+}
+
+func TestLazyFields(t *testing.T) {
+	calls := 0
+	expensive := func() interface{} {
+		calls++
+		return "computed"
+	}
+
+	for _, formatter := range []Formatter{TextFormatter{}, JSONFormatter{}, plainFormatter{}} {
+		calls = 0
+		w := &bytes.Buffer{}
+		logger := New(w, Nfo, false)
+		logger.SetFormatter(formatter)
+
+		scope := logger.WithScope(Fields{"count": LazyValue(expensive)}).With(Lazy("dump", expensive))
+
+		scope.Debug("filtered")
+		if calls != 0 {
+			t.Fatalf("lazy values should not be computed for filtered entries, got %d calls", calls)
+		}
+
+		scope.Info("emitted")
+		if calls != 2 || !strings.Contains(w.String(), "computed") {
+			t.Errorf("lazy values should be computed for emitted entries, got %d calls and %q", calls, w.String())
+		}
+	}
+}
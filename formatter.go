@@ -2,10 +2,10 @@ package wlog
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"time"
 )
 
@@ -16,6 +16,14 @@ type Formatter interface {
 	Format(w io.Writer, logLevel LogLevel, msg string, timestamp time.Time, fields Fields, fieldMapping FieldMapping) error
 }
 
+// FieldFormatter is implemented by formatters that are able to encode
+// typed fields directly. Formatters only implementing Formatter receive
+// the typed fields merged into fields
+type FieldFormatter interface {
+	Formatter
+	FormatFields(w io.Writer, logLevel LogLevel, msg string, timestamp time.Time, fields Fields, typed []Field, fieldMapping FieldMapping) error
+}
+
 // JSONFormatter used to output logs in JSON format
 type JSONFormatter struct {
 	Compact bool
@@ -24,6 +32,14 @@ type JSONFormatter struct {
 func (j JSONFormatter) getKey(key string, fieldMapping FieldMapping, isCustomField bool) string {
 	if j.Compact {
 		if !isCustomField {
+			switch key {
+			case "message":
+				return "@m"
+			case "timestamp":
+				return "@t"
+			case "level":
+				return "@l"
+			}
 			return "@" + key[:1]
 		}
 		var mappedKey string
@@ -40,33 +56,170 @@ func (j JSONFormatter) getKey(key string, fieldMapping FieldMapping, isCustomFie
 
 // Format implements Formatter.Format to support JSON
 func (j JSONFormatter) Format(w io.Writer, logLevel LogLevel, msg string, timestamp time.Time, fields Fields, fieldMapping FieldMapping) error {
+	return j.FormatFields(w, logLevel, msg, timestamp, fields, nil, fieldMapping)
+}
+
+// FormatFields implements FieldFormatter.FormatFields to support JSON.
+// Standard and map based fields are written in key order followed by
+// the typed fields in the order they were added. If several fields have
+// the same key the last typed one is written. Fields clashing with the
+// standard fields are written with the key prefixed by "fields."
+func (j JSONFormatter) FormatFields(w io.Writer, logLevel LogLevel, msg string, timestamp time.Time, fields Fields, typed []Field, fieldMapping FieldMapping) error {
 
-	// Standard fields
-	out := Fields{
-		j.getKey("message", fieldMapping, false):   msg,
-		j.getKey("timestamp", fieldMapping, false): getTimestamp(timestamp),
-		j.getKey("level", fieldMapping, false):     logLevel.String(),
+	// Standard fields, in key order
+	standard := [3]struct{ key, value string }{
+		{j.getKey("message", fieldMapping, false), msg},
+		{j.getKey("timestamp", fieldMapping, false), getTimestamp(timestamp)},
+		{j.getKey("level", fieldMapping, false), logLevel.String()},
+	}
+	for i := 1; i < len(standard); i++ {
+		for n := i; n > 0 && standard[n].key < standard[n-1].key; n-- {
+			standard[n], standard[n-1] = standard[n-1], standard[n]
+		}
 	}
 
-	// And any custom ones
-	for k, v := range fields {
-		out[j.getKey(k, fieldMapping, true)] = v
+	// customKey returns the key of a custom field, prefixed
+	// if it clashes with a standard field
+	customKey := func(key string) string {
+		key = j.getKey(key, fieldMapping, true)
+		if key == standard[0].key || key == standard[1].key || key == standard[2].key {
+			return "fields." + key
+		}
+		return key
 	}
 
-	encoder := json.NewEncoder(w)
-	if err := encoder.Encode(out); err != nil {
-		return fmt.Errorf("failed to marshal fields to JSON, %v", err)
+	tk := newTypedKeys(typed, customKey)
+
+	// And any custom ones not overridden by a typed field
+	var custom fieldKeys
+	if len(fields) > 0 {
+		custom = make(fieldKeys, 0, len(fields))
+		for k, v := range fields {
+			key := customKey(k)
+			if !tk.has(key) {
+				custom = append(custom, fieldKey{key: key, value: v})
+			}
+		}
+		sort.Sort(custom)
+	}
+
+	enc := &jsonEncoder{w: w}
+
+	writeString(w, "{")
+	s := standard[:]
+	for len(s) > 0 || len(custom) > 0 {
+		if len(custom) == 0 || len(s) > 0 && s[0].key <= custom[0].key {
+			enc.AddString(s[0].key, s[0].value)
+			s = s[1:]
+		} else {
+			enc.AddAny(custom[0].key, custom[0].value)
+			custom = custom[1:]
+		}
+	}
+	for i, f := range typed {
+		if tk.isLast(i) {
+			f.Key = customKey(f.Key)
+			f.AddTo(enc)
+		}
+	}
+	writeString(w, "}\n")
+
+	if enc.err != nil {
+		return fmt.Errorf("failed to marshal fields to JSON, %v", enc.err)
 	}
 
 	return nil
 }
 
+// fieldKey is a map based field to be written in key order
+type fieldKey struct {
+	key   string
+	value interface{}
+}
+
+// fieldKeys implements sort.Interface, ordering fields by key
+type fieldKeys []fieldKey
+
+func (f fieldKeys) Len() int           { return len(f) }
+func (f fieldKeys) Less(i, j int) bool { return f[i].key < f[j].key }
+func (f fieldKeys) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+
+// maxScanFields is the number of typed fields up to which duplicate
+// keys are found by scanning the fields rather than indexing them
+const maxScanFields = 16
+
+// typedKeys finds duplicate keys of typed fields, so that only the last
+// field with a key is written. Keys are compared as returned by key, or
+// as is if key is nil. Skipped fields are ignored
+type typedKeys struct {
+	typed []Field
+	key   func(string) string
+	last  map[string]int
+}
+
+func newTypedKeys(typed []Field, key func(string) string) typedKeys {
+	t := typedKeys{typed: typed, key: key}
+	if len(typed) > maxScanFields {
+		t.last = make(map[string]int, len(typed))
+		for i, f := range typed {
+			if f.Type != SkipType {
+				t.last[t.keyOf(i)] = i
+			}
+		}
+	}
+	return t
+}
+
+func (t typedKeys) keyOf(i int) string {
+	if t.key == nil {
+		return t.typed[i].Key
+	}
+	return t.key(t.typed[i].Key)
+}
+
+// has returns whether a typed field has key
+func (t typedKeys) has(key string) bool {
+	if t.last != nil {
+		_, ok := t.last[key]
+		return ok
+	}
+	for i := range t.typed {
+		if t.typed[i].Type != SkipType && t.keyOf(i) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// isLast returns whether typed[i] is written, i.e. it's not skipped
+// and no later field has the same key
+func (t typedKeys) isLast(i int) bool {
+	if t.typed[i].Type == SkipType {
+		return false
+	}
+	if t.last != nil {
+		return t.last[t.keyOf(i)] == i
+	}
+	key := t.keyOf(i)
+	for n := i + 1; n < len(t.typed); n++ {
+		if t.typed[n].Type != SkipType && t.keyOf(n) == key {
+			return false
+		}
+	}
+	return true
+}
+
 // TextFormatter used to output logs in text format. This is the default
 // formatter when creating a instance of wlog.
 type TextFormatter struct{}
 
 // Format Implements Formatter.Format to support Text
 func (t TextFormatter) Format(w io.Writer, logLevel LogLevel, msg string, timestamp time.Time, fields Fields, fieldMapping FieldMapping) error {
+	return t.FormatFields(w, logLevel, msg, timestamp, fields, nil, fieldMapping)
+}
+
+// FormatFields implements FieldFormatter.FormatFields to support Text
+func (t TextFormatter) FormatFields(w io.Writer, logLevel LogLevel, msg string, timestamp time.Time, fields Fields, typed []Field, fieldMapping FieldMapping) error {
 
 	// Write date and time
 	writeString(w, getTimestamp(timestamp))
@@ -76,6 +229,8 @@ func (t TextFormatter) Format(w io.Writer, logLevel LogLevel, msg string, timest
 	// Write log level
 	var level string
 	switch logLevel {
+	case Trc:
+		level = "TRC "
 	case Dbg:
 		level = "DBG "
 	case Nfo:
@@ -84,6 +239,8 @@ func (t TextFormatter) Format(w io.Writer, logLevel LogLevel, msg string, timest
 		level = "WRN "
 	case Err:
 		level = "ERR "
+	case Pnc:
+		level = "PNC "
 	case Ftl:
 		level = "FTL "
 	}
@@ -93,9 +250,9 @@ func (t TextFormatter) Format(w io.Writer, logLevel LogLevel, msg string, timest
 	// Append log message to buffer
 	writeString(w, msg)
 
-	if len(fields) > 0 {
+	if hasFields(fields, typed) {
 		writeString(w, " [")
-		writeFields(w, fields)
+		writeFields(&textEncoder{w: w}, fields, typed)
 		writeString(w, "]")
 	}
 
@@ -106,18 +263,45 @@ func (t TextFormatter) Format(w io.Writer, logLevel LogLevel, msg string, timest
 	return nil
 }
 
-func writeFields(w io.Writer, fields Fields) {
-	count := len(fields)
-	idx := 0
+func writeFields(enc FieldEncoder, fields Fields, typed []Field) {
+	tk := newTypedKeys(typed, nil)
 	for key, value := range fields {
-		idx++
-		writeString(w, key)
-		writeString(w, ": ")
-		writeString(w, fmt.Sprintf("%v", value))
-		if idx < count {
-			writeString(w, ", ")
+		if !tk.has(key) {
+			enc.AddAny(key, value)
+		}
+	}
+	for i, f := range typed {
+		if tk.isLast(i) {
+			f.AddTo(enc)
+		}
+	}
+}
+
+func hasFields(fields Fields, typed []Field) bool {
+	if len(fields) > 0 {
+		return true
+	}
+	for i := range typed {
+		if typed[i].Type != SkipType {
+			return true
 		}
 	}
+	return false
+}
+
+// format formats an entry using formatter. Typed fields are passed on to
+// formatters implementing FieldFormatter and merged into fields otherwise.
+// Lazy values are resolved while formatting, after the log level is checked
+func format(formatter Formatter, w io.Writer, logLevel LogLevel, msg string, timestamp time.Time, fields Fields, typed []Field, fieldMapping FieldMapping) error {
+	if ff, ok := formatter.(FieldFormatter); ok {
+		return ff.FormatFields(w, logLevel, msg, timestamp, fields, typed, fieldMapping)
+	}
+	if len(typed) > 0 {
+		fields = mergeFields(fields, typed)
+	} else {
+		fields = resolveFields(fields)
+	}
+	return formatter.Format(w, logLevel, msg, timestamp, fields, fieldMapping)
 }
 
 func getTimestamp(timestamp time.Time) string {
@@ -2,6 +2,7 @@ package wlog
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
@@ -52,3 +53,57 @@ func TestJSONFormatter(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteJSONString(t *testing.T) {
+	for _, s := range []string{"", "plain", `quote " and \ slash`, "<html>&", "line\nbreak\ttab\r\b\f\x01", "unicode ✓ \u2028 \u2029", "invalid \xff utf8"} {
+		want, _ := json.Marshal(s)
+		buf := &bytes.Buffer{}
+		writeJSONString(buf, s)
+		if got := buf.String(); got != string(want) {
+			t.Errorf("writeJSONString(%q) = %s, want %s", s, got, want)
+		}
+	}
+}
+
+func TestJSONFormatterDuplicateKeys(t *testing.T) {
+	now := time.Now()
+	buf := &bytes.Buffer{}
+
+	typed := []Field{String("a", "1"), String("b", "1"), String("a", "2"), String("message", "m")}
+	if err := (JSONFormatter{}).FormatFields(buf, Nfo, "hi", now, Fields{"b": "0", "level": "custom"}, typed, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := fmt.Sprintf(`{"fields.level":"custom","level":"Info","message":"hi","timestamp":"%s","b":"1","a":"2","fields.message":"m"}`, getTimestamp(now))
+	if got := strings.TrimSuffix(buf.String(), "\n"); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Many typed fields are indexed rather than scanned
+	buf.Reset()
+	typed = nil
+	for i := 0; i <= maxScanFields; i++ {
+		typed = append(typed, Int("n", i))
+	}
+	if err := (JSONFormatter{Compact: true}).FormatFields(buf, Nfo, "hi", now, Fields{"n": "map"}, typed, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want = fmt.Sprintf(`{"@l":"Info","@m":"hi","@t":"%s","n":%d}`, getTimestamp(now), maxScanFields)
+	if got := strings.TrimSuffix(buf.String(), "\n"); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestTextFormatterDuplicateKeys(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	typed := []Field{String("a", "1"), String("b", "1"), String("a", "2")}
+	if err := (TextFormatter{}).FormatFields(buf, Nfo, "hi", time.Now(), Fields{"b": "0"}, typed, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); !strings.HasSuffix(got, "NFO hi [b: 1, a: 2]\n") {
+		t.Errorf("unexpected log output %q", got)
+	}
+}
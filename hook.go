@@ -0,0 +1,268 @@
+package wlog
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Entry is a log entry as passed to hooks
+type Entry struct {
+	Time    time.Time
+	Level   LogLevel
+	Message string
+
+	// Fields holds the fields of the scope the entry was written
+	// through, with typed fields added and lazy values resolved.
+	// The map is owned by the entry and may be modified
+	Fields Fields
+
+	// Caller is the location the entry was written from. It is only
+	// set when caller reporting is enabled with SetReportCaller
+	Caller *runtime.Frame
+}
+
+// clone returns a copy of the entry with its own Fields
+func (e *Entry) clone() *Entry {
+	c := *e
+	c.Fields = make(Fields, len(e.Fields))
+	for k, v := range e.Fields {
+		c.Fields[k] = v
+	}
+	return &c
+}
+
+// Hook is implemented by hooks installed with AddHook. An error returned
+// by Fire is reported on standard error
+type Hook interface {
+	Fire(entry *Entry) error
+}
+
+// EntryHookFunc is an adapter allowing a function to be used as a Hook
+type EntryHookFunc func(entry *Entry) error
+
+// Fire implements Hook.Fire by calling f
+func (f EntryHookFunc) Fire(entry *Entry) error {
+	return f(entry)
+}
+
+// installedHook is a hook installed on a logger for entries at
+// logLevel and above, or only at logLevel if exact is set. The
+// id identifies the hook when it's removed
+type installedHook struct {
+	id       uint64
+	logLevel LogLevel
+	exact    bool
+	hook     Hook
+}
+
+func (h installedHook) matches(logLevel LogLevel) bool {
+	if h.exact {
+		return logLevel == h.logLevel
+	}
+	return logLevel.severity() >= h.logLevel.severity()
+}
+
+// removeHook returns a copy of hooks without the hook identified by id.
+// The slice is copied since it may be in use by a write in progress
+func removeHook(hooks []installedHook, id uint64) []installedHook {
+	remaining := make([]installedHook, 0, len(hooks))
+	for _, h := range hooks {
+		if h.id != id {
+			remaining = append(remaining, h)
+		}
+	}
+	return remaining
+}
+
+// hookFunc adapts a HookFunc to a Hook
+type hookFunc HookFunc
+
+func (f hookFunc) Fire(entry *Entry) error {
+	f(entry.Time, entry.Level, entry.Message)
+	return nil
+}
+
+// fireHooks calls the hooks matching the level of an entry. The entry
+// is only created if there is a matching hook
+func fireHooks(hooks []installedHook, reportCaller bool, timestamp time.Time, logLevel LogLevel, msg string, fields Fields, typed []Field) {
+	var entry *Entry
+
+	for _, h := range hooks {
+		if !h.matches(logLevel) {
+			continue
+		}
+
+		if entry == nil {
+			entry = &Entry{
+				Time:    timestamp,
+				Level:   logLevel,
+				Message: msg,
+				Fields:  mergeFields(fields, typed),
+			}
+			if reportCaller {
+				entry.Caller = caller()
+			}
+		}
+
+		if err := fireHook(h.hook, entry); err != nil {
+			fmt.Fprintf(os.Stderr, "hook failed for log entry: %v", err)
+		}
+	}
+}
+
+// fireHook calls hook, turning a panic into an error so that a
+// crashing hook doesn't take down the caller
+func fireHook(hook Hook, entry *Entry) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("hook panicked: %v", v)
+		}
+	}()
+	return hook.Fire(entry)
+}
+
+// packagePath is the import path of this package
+const packagePath = "github.com/vargspjut/wlog"
+
+// caller returns the first frame on the stack outside of this package
+// and its subpackages
+func caller() *runtime.Frame {
+	var pcs [32]uintptr
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+
+		inPackage := strings.HasPrefix(frame.Function, packagePath+".") || strings.HasPrefix(frame.Function, packagePath+"/")
+		if !inPackage || strings.HasSuffix(frame.File, "_test.go") {
+			return &frame
+		}
+
+		if !more {
+			return nil
+		}
+	}
+}
+
+// AsyncOptions configures an AsyncHook
+type AsyncOptions struct {
+	// QueueSize is the number of entries that may be waiting to be
+	// dispatched. Entries are dropped when the queue is full. Defaults
+	// to 1024
+	QueueSize int
+
+	// Workers is the number of goroutines dispatching entries.
+	// Defaults to 1
+	Workers int
+}
+
+// AsyncHook is a Hook dispatching entries to another Hook from worker
+// goroutines rather than the goroutine writing the entry
+type AsyncHook struct {
+	// Accessed atomically and kept first for alignment
+	dropped uint64
+	failed  uint64
+
+	hook  Hook
+	queue chan *Entry
+	wg    sync.WaitGroup
+
+	mutex  sync.RWMutex
+	closed bool
+}
+
+// NewAsyncHook returns an AsyncHook dispatching entries to hook. Call Close
+// to dispatch the entries waiting in the queue and stop the workers, e.g.
+// from an exit handler. opts may be nil
+func NewAsyncHook(hook Hook, opts *AsyncOptions) *AsyncHook {
+	if opts == nil {
+		opts = &AsyncOptions{}
+	}
+
+	queueSize := opts.QueueSize
+	if queueSize <= 0 {
+		queueSize = 1024
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	h := &AsyncHook{
+		hook:  hook,
+		queue: make(chan *Entry, queueSize),
+	}
+
+	h.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go h.work()
+	}
+
+	return h
+}
+
+// Fire implements Hook.Fire by queueing a copy of entry. The entry is
+// dropped if the queue is full or the hook is closed
+func (h *AsyncHook) Fire(entry *Entry) error {
+	e := entry.clone()
+
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	if h.closed {
+		atomic.AddUint64(&h.dropped, 1)
+		return nil
+	}
+
+	select {
+	case h.queue <- e:
+	default:
+		atomic.AddUint64(&h.dropped, 1)
+	}
+
+	return nil
+}
+
+// Dropped returns the number of entries dropped since the queue was full
+// or the hook closed
+func (h *AsyncHook) Dropped() uint64 {
+	return atomic.LoadUint64(&h.dropped)
+}
+
+// Failed returns the number of entries the wrapped hook failed
+// or panicked for
+func (h *AsyncHook) Failed() uint64 {
+	return atomic.LoadUint64(&h.failed)
+}
+
+// Close dispatches the entries waiting in the queue and stops the
+// workers. Entries fired after Close are dropped
+func (h *AsyncHook) Close() error {
+	h.mutex.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.queue)
+	}
+	h.mutex.Unlock()
+
+	h.wg.Wait()
+	return nil
+}
+
+func (h *AsyncHook) work() {
+	defer h.wg.Done()
+
+	for entry := range h.queue {
+		if err := fireHook(h.hook, entry); err != nil {
+			atomic.AddUint64(&h.failed, 1)
+			fmt.Fprintf(os.Stderr, "hook failed for log entry: %v", err)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package wlog
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAddHook(t *testing.T) {
+	logger := New(nil, Nfo, false)
+	logger.SetReportCaller(true)
+
+	var entries []*Entry
+	logger.AddHook(Wrn, EntryHookFunc(func(entry *Entry) error {
+		entries = append(entries, entry)
+		return nil
+	}))
+
+	scope := logger.WithScope(Fields{"tenant": "acme"}).With(Int("attempt", 2))
+	scope.Info("below the hook level")
+	scope.Warning("warning")
+	scope.Error("error")
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	entry := entries[1]
+	if entry.Level != Err || entry.Message != "error" || entry.Time.IsZero() {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if entry.Fields["tenant"] != "acme" || entry.Fields["attempt"] != int64(2) {
+		t.Errorf("unexpected entry fields %v", entry.Fields)
+	}
+	if entry.Caller == nil || filepath.Base(entry.Caller.File) != "hook_test.go" {
+		t.Errorf("unexpected caller %+v", entry.Caller)
+	}
+}
+
+func TestInstallHookExactLevel(t *testing.T) {
+	logger := New(nil, Dbg, false)
+
+	var levels []LogLevel
+	logger.InstallHook(Wrn, func(_ time.Time, logLevel LogLevel, _ string) {
+		levels = append(levels, logLevel)
+	})
+
+	logger.Warning("warning")
+	logger.Error("error")
+
+	if len(levels) != 1 || levels[0] != Wrn {
+		t.Errorf("expected the hook to be called for warnings only, got %v", levels)
+	}
+}
+
+func TestRemoveHook(t *testing.T) {
+	logger := New(nil, Nfo, false)
+
+	var first, second int
+	removeFirst := logger.AddHook(Nfo, EntryHookFunc(func(*Entry) error {
+		first++
+		return nil
+	}))
+	logger.InstallHook(Nfo, func(time.Time, LogLevel, string) {
+		second++
+	})
+
+	logger.Info("both hooks")
+	removeFirst()
+	removeFirst()
+	logger.Info("second hook only")
+
+	if first != 1 || second != 2 {
+		t.Errorf("unexpected hook calls, first %d second %d", first, second)
+	}
+
+	logger.ClearHooks()
+	logger.Info("no hooks")
+	if second != 2 {
+		t.Errorf("expected no hooks after ClearHooks, got %d calls", second)
+	}
+}
+
+func TestHookCanLog(t *testing.T) {
+	logger := New(nil, Nfo, false)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		logged := false
+		logger.AddHook(Err, EntryHookFunc(func(entry *Entry) error {
+			if !logged {
+				logged = true
+				logger.Error("logged from hook")
+			}
+			return nil
+		}))
+		logger.Error("error")
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging from a hook deadlocked")
+	}
+}
+
+func TestHookPanic(t *testing.T) {
+	logger := New(nil, Nfo, false)
+
+	var called bool
+	logger.AddHook(Nfo, EntryHookFunc(func(*Entry) error {
+		panic("hook crashed")
+	}))
+	logger.AddHook(Nfo, EntryHookFunc(func(*Entry) error {
+		called = true
+		return nil
+	}))
+
+	logger.Info("entry")
+
+	if !called {
+		t.Error("expected hooks after a panicking hook to be called")
+	}
+}
+
+func TestAsyncHook(t *testing.T) {
+	logger := New(nil, Nfo, false)
+
+	release := make(chan struct{})
+	var messages []string
+	hook := NewAsyncHook(EntryHookFunc(func(entry *Entry) error {
+		<-release
+		messages = append(messages, entry.Message)
+		if entry.Message == "third" {
+			panic("hook crashed")
+		}
+		return nil
+	}), &AsyncOptions{QueueSize: 2})
+	logger.AddHook(Nfo, hook)
+
+	logger.Info("first")
+	// Wait for the worker to pick up the first entry
+	for len(hook.queue) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	logger.Info("second")
+	logger.Info("third")
+	logger.Info("dropped")
+
+	close(release)
+	hook.Close()
+	logger.Info("closed")
+
+	if len(messages) != 3 || messages[0] != "first" || messages[2] != "third" {
+		t.Errorf("unexpected messages %v", messages)
+	}
+	if hook.Dropped() != 2 {
+		t.Errorf("expected 2 dropped entries, got %d", hook.Dropped())
+	}
+	if hook.Failed() != 1 {
+		t.Errorf("expected 1 failed entry, got %d", hook.Failed())
+	}
+}
+
+func TestScopeHooks(t *testing.T) {
+	logger := New(nil, Nfo, false)
+
+	var root, tenant, job []string
+	logger.AddHook(Nfo, EntryHookFunc(func(entry *Entry) error {
+		root = append(root, entry.Message)
+		return nil
+	}))
+
+	tenantScope := logger.WithScope(Fields{"tenant": "acme"})
+	jobScope := tenantScope.With(String("job", "import"))
+
+	tenantScope.AddHook(Nfo, EntryHookFunc(func(entry *Entry) error {
+		tenant = append(tenant, entry.Message)
+		return nil
+	}))
+	removeJob := jobScope.InstallHook(Wrn, func(_ time.Time, _ LogLevel, msg string) {
+		job = append(job, msg)
+	})
+
+	logger.Info("root")
+	tenantScope.Info("tenant")
+	jobScope.Warning("job")
+	removeJob()
+	jobScope.Warning("job removed")
+	tenantScope.WithScope(Fields{"sibling": true}).Info("sibling")
+
+	if len(root) != 5 {
+		t.Errorf("expected the root hook to be called for every entry, got %v", root)
+	}
+	if len(tenant) != 4 || tenant[0] != "tenant" {
+		t.Errorf("unexpected tenant hook calls %v", tenant)
+	}
+	if len(job) != 1 || job[0] != "job" {
+		t.Errorf("unexpected job hook calls %v", job)
+	}
+}
+
+func TestHookLazyValues(t *testing.T) {
+	logger := New(&bytes.Buffer{}, Nfo, false)
+
+	var fieldCalls, typedCalls int
+	var fields Fields
+	logger.AddHook(Nfo, EntryHookFunc(func(entry *Entry) error {
+		fields = entry.Fields
+		return nil
+	}))
+
+	logger.WithScope(Fields{"scoped": LazyValue(func() interface{} {
+		fieldCalls++
+		return "scoped value"
+	})}).With(Lazy("typed", func() interface{} {
+		typedCalls++
+		return 42
+	})).Info("entry")
+
+	if fieldCalls != 1 || typedCalls != 1 {
+		t.Errorf("expected lazy values to be computed once, got %d and %d calls", fieldCalls, typedCalls)
+	}
+	if fields["scoped"] != "scoped value" || fields["typed"] != int64(42) {
+		t.Errorf("unexpected entry fields %v", fields)
+	}
+}
@@ -0,0 +1,338 @@
+package wlog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// The names of the fields of access log entries written by HTTPMiddleware
+const (
+	MethodField     = "method"
+	PathField       = "path"
+	QueryField      = "query"
+	ProtoField      = "proto"
+	StatusField     = "status"
+	BytesField      = "bytes"
+	DurationField   = "duration"
+	RemoteAddrField = "remote_addr"
+	UserAgentField  = "user_agent"
+	RefererField    = "referer"
+	UserField       = "user"
+)
+
+// RequestIDField is the name of the field holding the request id. It
+// is subject to the FieldMapping of the logger
+const RequestIDField = "request_id"
+
+// DefaultRequestIDHeader is the header used for request ids unless
+// HTTPOptions.RequestIDHeader is set
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength is the maximum length of a request id accepted
+// from a request header. Longer ids are replaced by a generated one
+const maxRequestIDLength = 128
+
+// HTTPOptions configures HTTPMiddleware
+type HTTPOptions struct {
+	// Skip, if set, is called for every request. No access log
+	// entry is written for requests it returns true for
+	Skip func(r *http.Request) bool
+
+	// RequestLogger is the logger request-scoped loggers are based on.
+	// Defaults to the logger passed to HTTPMiddleware
+	RequestLogger Logger
+
+	// RequestIDHeader is the request and response header holding
+	// the request id. Defaults to DefaultRequestIDHeader
+	RequestIDHeader string
+
+	// GenerateRequestID returns ids for requests without one.
+	// Defaults to 16 random bytes in hex
+	GenerateRequestID func() string
+}
+
+// HTTPMiddleware returns a middleware writing one access log entry to logger
+// per request. The entry has the method, path, status, bytes, duration, remote
+// address and user agent of the request as fields. Server errors (5xx) are
+// logged as errors, client errors (4xx) as warnings and anything else as info.
+// Set a CommonLogFormatter on logger to write Apache Common or Combined Log
+// Format.
+//
+// Every request gets a scoped logger with the request id, taken from the
+// request header or generated, and the trace context of the request as
+// fields. The logger is stored in the request context where handlers
+// retrieve it with FromRequest, and the request id is echoed in the
+// response header. opts may be nil
+func HTTPMiddleware(logger Logger, opts *HTTPOptions) func(http.Handler) http.Handler {
+	if opts == nil {
+		opts = &HTTPOptions{}
+	}
+
+	requestLogger := opts.RequestLogger
+	if requestLogger == nil {
+		requestLogger = logger
+	}
+
+	header := opts.RequestIDHeader
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+
+	generate := opts.GenerateRequestID
+	if generate == nil {
+		generate = generateRequestID
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(header)
+			if requestID == "" || len(requestID) > maxRequestIDLength {
+				requestID = generate()
+			}
+			w.Header().Set(header, requestID)
+
+			idField := String(mapKey(RequestIDField, requestLogger.GetFieldMapping()), requestID)
+			scoped := requestLogger.With(idField)
+
+			ctx := r.Context()
+			if tc, ok := TraceContextFromRequest(r); ok {
+				scoped = WithTrace(scoped, tc)
+				ctx = ContextWithTrace(ctx, tc)
+			}
+			r = r.WithContext(NewContext(ctx, scoped))
+
+			if opts.Skip != nil && opts.Skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			rw := &responseWriter{ResponseWriter: w}
+			completed := false
+
+			defer func() {
+				// The handler panicked, log it as a server error
+				if !completed {
+					rw.status = http.StatusInternalServerError
+				}
+				logAccess(logger.With(idField), r, rw, time.Since(start))
+			}()
+
+			next.ServeHTTP(rw, r)
+			completed = true
+		})
+	}
+}
+
+// FromRequest returns the request-scoped Logger stored in the context of r
+// by HTTPMiddleware. The default logger is returned if there is none
+func FromRequest(r *http.Request) Logger {
+	return FromContext(r.Context())
+}
+
+func generateRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// Fall back on something unique enough
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hexString(b[:])
+}
+
+func hexString(b []byte) string {
+	buf := make([]byte, len(b)*2)
+	for i, c := range b {
+		buf[i*2] = hex[c>>4]
+		buf[i*2+1] = hex[c&0x0f]
+	}
+	return string(buf)
+}
+
+func logAccess(logger Logger, r *http.Request, rw *responseWriter, elapsed time.Duration) {
+	status := rw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	logLevel := Nfo
+	switch {
+	case status >= 500:
+		logLevel = Err
+	case status >= 400:
+		logLevel = Wrn
+	}
+
+	if logLevel.severity() < logger.GetLogLevel().severity() {
+		return
+	}
+
+	fields := make([]Field, 0, 11)
+	fields = append(fields,
+		String(MethodField, r.Method),
+		String(PathField, r.URL.Path),
+		String(ProtoField, r.Proto),
+		Any(StatusField, accessStatus(status)),
+		Int64(BytesField, rw.bytes),
+		Duration(DurationField, elapsed),
+		String(RemoteAddrField, r.RemoteAddr),
+		String(UserAgentField, r.UserAgent()),
+	)
+	if r.URL.RawQuery != "" {
+		fields = append(fields, String(QueryField, r.URL.RawQuery))
+	}
+	if referer := r.Referer(); referer != "" {
+		fields = append(fields, String(RefererField, referer))
+	}
+	if user, _, ok := r.BasicAuth(); ok && user != "" {
+		fields = append(fields, String(UserField, user))
+	}
+
+	logAt(logger.With(fields...), logLevel, r.Method+" "+r.URL.Path+" "+strconv.Itoa(status))
+}
+
+// accessStatus is the type of the status field of access log entries,
+// telling them apart from other entries with a status field
+type accessStatus int
+
+// responseWriter wraps an http.ResponseWriter to record
+// the status and number of bytes written
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher if the wrapped ResponseWriter does
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker if the wrapped ResponseWriter does
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("http.Hijacker is not supported")
+}
+
+// Unwrap returns the wrapped ResponseWriter for use with http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// CommonLogFormatter is used to output access log entries written by
+// HTTPMiddleware in Apache Common Log Format, or Combined Log Format if
+// Combined is set. Other entries, including entries with a status field
+// not written by HTTPMiddleware, are written by TextFormatter
+type CommonLogFormatter struct {
+	Combined bool
+}
+
+// Format implements Formatter.Format to support Common and Combined Log Format
+func (c CommonLogFormatter) Format(w io.Writer, logLevel LogLevel, msg string, timestamp time.Time, fields Fields, fieldMapping FieldMapping) error {
+	if _, ok := fields[StatusField].(accessStatus); !ok {
+		return TextFormatter{}.Format(w, logLevel, msg, timestamp, fields, fieldMapping)
+	}
+
+	host := fieldString(fields, RemoteAddrField)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	uri := fieldString(fields, PathField)
+	if query := fieldString(fields, QueryField); query != "-" {
+		uri += "?" + query
+	}
+
+	bytes := fieldString(fields, BytesField)
+	if bytes == "0" {
+		bytes = "-"
+	}
+
+	// host ident authuser [date] "request" status bytes
+	writeString(w, fmt.Sprintf(`%s - %s [%s] "%s %s %s" %s %s`,
+		host,
+		fieldString(fields, UserField),
+		timestamp.Format("02/Jan/2006:15:04:05 -0700"),
+		fieldString(fields, MethodField),
+		uri,
+		fieldString(fields, ProtoField),
+		fieldString(fields, StatusField),
+		bytes,
+	))
+
+	if c.Combined {
+		// "referer" "user-agent"
+		writeString(w, fmt.Sprintf(` "%s" "%s"`, fieldString(fields, RefererField), fieldString(fields, UserAgentField)))
+	}
+
+	writeString(w, "\n")
+
+	return nil
+}
+
+// fieldString returns the value of a field formatted as a string and
+// escaped, or "-" if it's missing or empty
+func fieldString(fields Fields, key string) string {
+	v, ok := fields[key]
+	if !ok {
+		return "-"
+	}
+	if s := fmt.Sprint(v); s != "" {
+		return escapeCLF(s)
+	}
+	return "-"
+}
+
+// escapeCLF escapes '"' and '\' with a backslash and control characters
+// as \xhh, as Apache does, keeping values from breaking out of their field
+func escapeCLF(s string) string {
+	i := 0
+	for ; i < len(s); i++ {
+		if c := s[i]; c == '"' || c == '\\' || c < 0x20 || c == 0x7f {
+			break
+		}
+	}
+	if i == len(s) {
+		return s
+	}
+
+	buf := make([]byte, 0, len(s)+8)
+	buf = append(buf, s[:i]...)
+	for ; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			buf = append(buf, '\\', c)
+		case c < 0x20 || c == 0x7f:
+			buf = append(buf, '\\', 'x', hex[c>>4], hex[c&0x0f])
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return string(buf)
+}
@@ -0,0 +1,158 @@
+package wlog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+
+	handler := HTTPMiddleware(logger, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+
+	for _, tt := range []struct {
+		path string
+		want []string
+	}{
+		{"/hello?name=john", []string{" NFO GET /hello 200 [", "method: GET", "path: /hello", "status: 200", "bytes: 5", "query: name=john", "remote_addr: 192.0.2.1:1234", "user_agent: test-agent"}},
+		{"/missing", []string{" WRN GET /missing 404 [", "status: 404"}},
+		{"/fail", []string{" ERR GET /fail 500 [", "status: 500", "bytes: 0"}},
+	} {
+		w.Reset()
+
+		r := httptest.NewRequest("GET", tt.path, nil)
+		r.Header.Set("User-Agent", "test-agent")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		got := w.String()
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("output %q should contain %q", got, want)
+			}
+		}
+	}
+}
+
+func TestCommonLogFormatter(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+	logger.SetFormatter(CommonLogFormatter{Combined: true})
+
+	handler := HTTPMiddleware(logger, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+
+	r := httptest.NewRequest("GET", "/hello?name=john", nil)
+	r.Header.Set("User-Agent", "test-agent")
+	r.Header.Set("Referer", "http://example.com/")
+	r.SetBasicAuth("frank", "secret")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	want := regexp.MustCompile(`^192\.0\.2\.1 - frank \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /hello\?name=john HTTP/1\.1" 200 5 "http://example\.com/" "test-agent"\n$`)
+	if got := w.String(); !want.MatchString(got) {
+		t.Errorf("unexpected log output %q", got)
+	}
+
+	// Other entries with a status field are not access log entries
+	w.Reset()
+	logger.WithScope(Fields{StatusField: "active"}).Info("user logged in")
+	if got := w.String(); !strings.Contains(got, " NFO user logged in [status: active]") {
+		t.Errorf("unexpected log output %q", got)
+	}
+}
+
+func TestHTTPMiddlewareRequestLogger(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+
+	handler := HTTPMiddleware(logger, &HTTPOptions{Skip: func(r *http.Request) bool { return true }})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromRequest(r).Info("handling")
+		InfoCtx(r.Context(), "from context")
+	}))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(DefaultRequestIDHeader, "req-1")
+	r.Header.Set(TraceParentHeader, testTraceParent)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+
+	if got := rec.Header().Get(DefaultRequestIDHeader); got != "req-1" {
+		t.Errorf("response request id = %q, want %q", got, "req-1")
+	}
+
+	lines := strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 entries, got %q", lines)
+	}
+	for _, line := range lines {
+		if strings.Count(line, "trace_id") != 1 {
+			t.Errorf("entry %q should contain the trace id once", line)
+		}
+		for _, want := range []string{"request_id: req-1", "trace_id: 4bf92f3577b34da6a3ce929d0e0e4736", "span_id: 00f067aa0ba902b7"} {
+			if !strings.Contains(line, want) {
+				t.Errorf("entry %q should contain %q", line, want)
+			}
+		}
+	}
+
+	// A request id is generated when there is none
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if got := rec.Header().Get(DefaultRequestIDHeader); len(got) != 32 {
+		t.Errorf("expected a generated request id, got %q", got)
+	}
+}
+
+func TestHTTPMiddlewarePanic(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+
+	handler := HTTPMiddleware(logger, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	func() {
+		defer func() {
+			if v := recover(); v != "boom" {
+				t.Errorf("expected the panic to be passed on, got %v", v)
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+
+	if got := w.String(); !strings.Contains(got, " ERR GET / 500 [") {
+		t.Errorf("unexpected log output %q", got)
+	}
+}
+
+func TestCommonLogFormatterEscaping(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+	logger.SetFormatter(CommonLogFormatter{Combined: true})
+
+	handler := HTTPMiddleware(logger, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r := httptest.NewRequest("GET", "/a%22b", nil)
+	r.Header.Set("User-Agent", `agent" 200 5 "forged\`+"\n")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	want := `"GET /a\"b HTTP/1.1" 200 - "-" "agent\" 200 5 \"forged\\\x0a"` + "\n"
+	if got := w.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("expected suffix %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,295 @@
+package wlog
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMemorySinkSize is the number of entries kept by a
+// MemorySink unless another size is given
+const DefaultMemorySinkSize = 1000
+
+// subscriberQueueSize is the number of entries that may be waiting
+// to be streamed to a subscriber before entries are dropped
+const subscriberQueueSize = 64
+
+// MemorySink is a Hook keeping the last entries passed to it in memory,
+// e.g. for a debug page. Install it with AddHook
+type MemorySink struct {
+	mutex       sync.Mutex
+	entries     []*Entry
+	next        int
+	full        bool
+	subscribers map[chan *Entry]struct{}
+}
+
+// Query selects entries kept by a MemorySink. The zero value
+// selects all entries at Dbg and above
+type Query struct {
+	// MinLevel is the lowest level of the entries selected
+	MinLevel LogLevel
+
+	// Since and Until, if set, select entries written at or after
+	// Since and before Until
+	Since time.Time
+	Until time.Time
+
+	// Fields, if set, selects entries having all the fields with
+	// the same values, compared by their string representation
+	Fields Fields
+
+	// Limit, if positive, is the maximum number of entries
+	// selected. The most recent entries are kept
+	Limit int
+}
+
+// NewMemorySink returns a MemorySink keeping the last size entries. A
+// size of 0 or less means DefaultMemorySinkSize
+func NewMemorySink(size int) *MemorySink {
+	if size <= 0 {
+		size = DefaultMemorySinkSize
+	}
+
+	return &MemorySink{
+		entries:     make([]*Entry, size),
+		subscribers: map[chan *Entry]struct{}{},
+	}
+}
+
+// Fire implements Hook.Fire by keeping a copy of entry
+func (s *MemorySink) Fire(entry *Entry) error {
+	e := entry.clone()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.entries[s.next] = e
+	s.next = (s.next + 1) % len(s.entries)
+	if s.next == 0 {
+		s.full = true
+	}
+
+	// Subscribers that don't keep up miss entries
+	for ch := range s.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Entries returns the kept entries selected by q, oldest first. The
+// entries are shared and must not be modified
+func (s *MemorySink) Entries(q Query) []*Entry {
+	s.mutex.Lock()
+	var entries []*Entry
+	if s.full {
+		entries = append(entries, s.entries[s.next:]...)
+	}
+	entries = append(entries, s.entries[:s.next]...)
+	s.mutex.Unlock()
+
+	selected := entries[:0]
+	for _, e := range entries {
+		if q.matches(e) {
+			selected = append(selected, e)
+		}
+	}
+
+	if q.Limit > 0 && len(selected) > q.Limit {
+		selected = selected[len(selected)-q.Limit:]
+	}
+	return selected
+}
+
+// Subscribe returns a channel receiving copies of new entries, and a
+// function to call to unsubscribe. Entries are dropped if the channel
+// isn't read from fast enough
+func (s *MemorySink) Subscribe() (<-chan *Entry, func()) {
+	ch := make(chan *Entry, subscriberQueueSize)
+
+	s.mutex.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mutex.Unlock()
+
+	return ch, func() {
+		s.mutex.Lock()
+		delete(s.subscribers, ch)
+		s.mutex.Unlock()
+	}
+}
+
+func (q *Query) matches(e *Entry) bool {
+	if e.Level.severity() < q.MinLevel.severity() {
+		return false
+	}
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Time.Before(q.Until) {
+		return false
+	}
+	for k, want := range q.Fields {
+		v, ok := e.Fields[k]
+		if !ok || fmt.Sprint(v) != fmt.Sprint(want) {
+			return false
+		}
+	}
+	return true
+}
+
+// Handler returns an http.Handler serving the entries kept by the sink as
+// a JSON array, or streaming new entries as server-sent events if the
+// request accepts text/event-stream. Entries are selected with the query
+// parameters level (e.g. warning), since and until (RFC 3339), limit and
+// field, given as key:value and repeated for several fields
+func (s *MemorySink) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q, err := parseQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			s.stream(w, r, q)
+			return
+		}
+
+		var buf bytes.Buffer
+		buf.WriteString("[")
+		for i, e := range s.Entries(q) {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			writeJSONEntry(&buf, e)
+		}
+		buf.WriteString("]\n")
+
+		w.Header().Set("Content-Type", "application/json")
+		buf.WriteTo(w)
+	})
+}
+
+// stream writes new entries selected by q as server-sent
+// events until the request is done
+func (s *MemorySink) stream(w http.ResponseWriter, r *http.Request, q Query) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	entries, unsubscribe := s.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var buf bytes.Buffer
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-entries:
+			if !q.matches(e) {
+				continue
+			}
+
+			buf.Reset()
+			buf.WriteString("data: ")
+			writeJSONEntry(&buf, e)
+			buf.WriteString("\n\n")
+			if _, err := buf.WriteTo(w); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func parseQuery(r *http.Request) (Query, error) {
+	var q Query
+	values := r.URL.Query()
+
+	if level := values.Get("level"); level != "" {
+		logLevel, ok := parseLevel(level)
+		if !ok {
+			return q, fmt.Errorf("invalid level %q", level)
+		}
+		q.MinLevel = logLevel
+	}
+
+	for _, t := range []struct {
+		name  string
+		value *time.Time
+	}{
+		{"since", &q.Since},
+		{"until", &q.Until},
+	} {
+		if v := values.Get(t.name); v != "" {
+			parsed, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return q, fmt.Errorf("invalid %s: %v", t.name, err)
+			}
+			*t.value = parsed
+		}
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return q, fmt.Errorf("invalid limit %q", limit)
+		}
+		q.Limit = n
+	}
+
+	for _, field := range values["field"] {
+		i := strings.Index(field, ":")
+		if i < 0 {
+			return q, fmt.Errorf("invalid field %q, expected key:value", field)
+		}
+		if q.Fields == nil {
+			q.Fields = Fields{}
+		}
+		q.Fields[field[:i]] = field[i+1:]
+	}
+
+	return q, nil
+}
+
+// parseLevel returns the LogLevel with the given name, e.g. warning
+func parseLevel(name string) (LogLevel, bool) {
+	for _, logLevel := range levels {
+		if strings.EqualFold(name, logLevel.String()) {
+			return logLevel, true
+		}
+	}
+	return 0, false
+}
+
+// writeJSONEntry writes an entry as a JSON object
+func writeJSONEntry(buf *bytes.Buffer, e *Entry) {
+	enc := &jsonEncoder{w: buf}
+
+	buf.WriteString("{")
+	enc.AddTime("time", e.Time)
+	enc.AddString("level", levelLabel(e.Level))
+	enc.AddString("message", e.Message)
+	if len(e.Fields) > 0 {
+		enc.AddObject("fields", e.Fields)
+	}
+	if e.Caller != nil {
+		enc.AddString("caller", e.Caller.File+":"+strconv.Itoa(e.Caller.Line))
+	}
+	buf.WriteString("}")
+}
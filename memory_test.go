@@ -0,0 +1,107 @@
+package wlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemorySink(t *testing.T) {
+	logger := New(nil, Dbg, false)
+	sink := NewMemorySink(3)
+	logger.AddHook(Dbg, sink)
+
+	start := time.Now()
+	logger.Debug("evicted")
+	logger.Debug("debug")
+	logger.WithScope(Fields{"tenant": "acme"}).Warning("warning")
+	logger.With(Int("attempt", 2)).Error("error")
+
+	entries := sink.Entries(Query{})
+	if len(entries) != 3 || entries[0].Message != "debug" || entries[2].Message != "error" {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+
+	for _, tt := range []struct {
+		q    Query
+		want []string
+	}{
+		{Query{MinLevel: Wrn}, []string{"warning", "error"}},
+		{Query{Fields: Fields{"tenant": "acme"}}, []string{"warning"}},
+		{Query{Fields: Fields{"attempt": "2"}}, []string{"error"}},
+		{Query{Limit: 1}, []string{"error"}},
+		{Query{Until: start}, nil},
+		{Query{Since: start, Until: time.Now().Add(time.Second), MinLevel: Err}, []string{"error"}},
+	} {
+		var got []string
+		for _, e := range sink.Entries(tt.q) {
+			got = append(got, e.Message)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("query %+v: expected %v, got %v", tt.q, tt.want, got)
+		}
+	}
+}
+
+func TestMemorySinkHandler(t *testing.T) {
+	logger := New(nil, Dbg, false)
+	sink := NewMemorySink(10)
+	logger.AddHook(Dbg, sink)
+
+	logger.Info("started")
+	logger.WithScope(Fields{"tenant": "acme"}).Error("failed")
+
+	rec := httptest.NewRecorder()
+	sink.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/logs?level=error&field=tenant:acme", nil))
+
+	var entries []struct {
+		Time    time.Time
+		Level   string
+		Message string
+		Fields  map[string]interface{}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("%v: %s", err, rec.Body.String())
+	}
+	if len(entries) != 1 || entries[0].Level != "error" || entries[0].Message != "failed" || entries[0].Fields["tenant"] != "acme" {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+
+	rec = httptest.NewRecorder()
+	sink.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/logs?level=verbose", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for an invalid level, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestMemorySinkStream(t *testing.T) {
+	logger := New(nil, Dbg, false)
+	sink := NewMemorySink(10)
+	logger.AddHook(Dbg, sink)
+
+	server := httptest.NewServer(sink.Handler())
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"?level=warning", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	logger.Info("filtered")
+	logger.Warning("streamed")
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(line, "data: {") || !strings.Contains(line, `"message":"streamed"`) {
+		t.Errorf("unexpected event %q", line)
+	}
+}
@@ -0,0 +1,174 @@
+package wlog
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"runtime/debug"
+)
+
+// The names of the fields of entries written for recovered panics
+const (
+	PanicField = "panic"
+	StackField = "stack"
+)
+
+// RecoverAction controls what happens after a recovered panic is logged
+type RecoverAction int
+
+// The recover actions available
+const (
+	// RecoverSwallow stops the panic
+	RecoverSwallow RecoverAction = iota
+	// RecoverRepanic panics again with the recovered value
+	RecoverRepanic
+	// RecoverExit terminates the process
+	RecoverExit
+)
+
+// RecoverOptions configures Recover and RecoverMiddleware
+type RecoverOptions struct {
+	// Fatal logs recovered panics as Ftl rather than Err
+	Fatal bool
+
+	// Action is what to do once the panic is logged
+	Action RecoverAction
+
+	// ExitCode is the exit code used by RecoverExit. Defaults
+	// to the exit code of the logger
+	ExitCode int
+}
+
+// Recover recovers a panic, logs it with the panic value and stack trace as
+// fields, flushes the outputs of logger and then swallows the panic, panics
+// again or exits depending on opts. It must be deferred directly:
+//
+//	defer wlog.Recover(logger, nil)
+//
+// opts may be nil, in which case the panic is logged as an error and swallowed
+func Recover(logger Logger, opts *RecoverOptions) {
+	if v := recover(); v != nil {
+		handlePanic(logger, opts, v)
+	}
+}
+
+// RecoverMiddleware returns a middleware recovering panics in handlers. Panics
+// are logged using the request-scoped logger of HTTPMiddleware if there is
+// one, otherwise logger, and a 500 Internal Server Error is returned unless
+// the response has been started. http.ErrAbortHandler is passed on without
+// being logged. opts may be nil
+func RecoverMiddleware(logger Logger, opts *RecoverOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &responseWriter{ResponseWriter: w}
+
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+
+				if rw.status == 0 {
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+
+				l := logger
+				if scoped, ok := r.Context().Value(loggerKey{}).(Logger); ok {
+					l = scoped
+				}
+				handlePanic(l, opts, v)
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+func handlePanic(logger Logger, opts *RecoverOptions, v interface{}) {
+	if opts == nil {
+		opts = &RecoverOptions{}
+	}
+
+	logLevel := Err
+	if opts.Fatal {
+		logLevel = Ftl
+	}
+
+	logAt(logger.With(Any(PanicField, v), String(StackField, string(debug.Stack()))), logLevel, fmt.Sprintf("panic: %v", v))
+	flush(logger)
+
+	switch opts.Action {
+	case RecoverRepanic:
+		panic(v)
+	case RecoverExit:
+		exit(logger, opts.ExitCode)
+	}
+}
+
+// exit terminates the process through l if it is a logger of this package,
+// running its exit handlers and exit function, otherwise using os.Exit.
+// A code of 0 means the exit code of the logger
+func exit(l Logger, code int) {
+	switch l := l.(type) {
+	case *logger:
+		l.exit(code)
+	case *scopedLogger:
+		l.logger.exit(code)
+	default:
+		if code == 0 {
+			code = 1
+		}
+		os.Exit(code)
+	}
+}
+
+// syncer is implemented by writers that buffer output, e.g. *os.File
+type syncer interface {
+	Sync() error
+}
+
+// flusher is implemented by writers that buffer output, e.g. *bufio.Writer
+type flusher interface {
+	Flush() error
+}
+
+// flush flushes the outputs of l if it is a logger of this package
+func flush(l Logger) {
+	switch l := l.(type) {
+	case *logger:
+		l.flush()
+	case *scopedLogger:
+		l.logger.flush()
+	}
+}
+
+// flush writes any pending repeat and sampling summary entries, and
+// flushes the writer of the logger, if it buffers output, and
+// standard output if enabled
+func (l *logger) flush() {
+	l.flushRepeated()
+	l.flushSampled()
+
+	l.lock()
+	defer l.unlock()
+
+	switch w := l.writer.(type) {
+	case syncer:
+		if err := w.Sync(); err != nil {
+			fmt.Fprintf(os.Stderr, "could not flush io.Writer: %v", err)
+		}
+	case flusher:
+		if err := w.Flush(); err != nil {
+			fmt.Fprintf(os.Stderr, "could not flush io.Writer: %v", err)
+		}
+	}
+
+	if l.stdOut {
+		// Errors are expected for terminals and pipes
+		os.Stdout.Sync()
+		os.Stderr.Sync()
+	}
+}
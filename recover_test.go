@@ -0,0 +1,67 @@
+package wlog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+
+	func() {
+		defer Recover(logger.WithScope(Fields{"job": "cleanup"}), nil)
+		panic("boom")
+	}()
+
+	got := w.String()
+	for _, want := range []string{" ERR panic: boom [", "job: cleanup", "panic: boom", "stack: goroutine", "TestRecover"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output %q should contain %q", got, want)
+		}
+	}
+
+	w.Reset()
+	func() {
+		defer func() {
+			if v := recover(); v != "again" {
+				t.Errorf("expected the panic to be passed on, got %v", v)
+			}
+		}()
+		defer Recover(logger, &RecoverOptions{Fatal: true, Action: RecoverRepanic})
+		panic("again")
+	}()
+
+	if got := w.String(); !strings.Contains(got, " FTL panic: again [") {
+		t.Errorf("unexpected log output %q", got)
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+
+	handler := HTTPMiddleware(logger, nil)(RecoverMiddleware(logger, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	})))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(DefaultRequestIDHeader, "req-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n")
+	if !strings.Contains(lines[0], " ERR panic: handler failed [") || !strings.Contains(lines[0], "request_id: req-1") {
+		t.Errorf("unexpected log output %q", lines[0])
+	}
+	if last := lines[len(lines)-1]; !strings.Contains(last, " ERR GET / 500 [") {
+		t.Errorf("expected an access log entry, got %q", last)
+	}
+}
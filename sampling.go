@@ -0,0 +1,189 @@
+package wlog
+
+import (
+	"sync"
+	"time"
+)
+
+// SuppressedField is the name of the field holding the number of entries
+// suppressed by sampling in the summary entries written by a logger
+const SuppressedField = "suppressed"
+
+// SamplingConfig configures sampling of log entries. Entries with the same
+// level and message are counted per interval. The first entries of each
+// interval are written, after that only every Thereafter:th entry. At the
+// end of an interval a summary entry with the number of suppressed entries
+// as a field is written
+type SamplingConfig struct {
+	// Interval is the period entries are counted over. Defaults to a second
+	Interval time.Duration
+
+	// First is the number of entries written per interval before sampling
+	First int
+
+	// Thereafter is the sampling rate once First entries have been written.
+	// If zero, all entries after the first are suppressed
+	Thereafter int
+}
+
+type sampleKey struct {
+	logLevel LogLevel
+	msg      string
+}
+
+type sampleCount struct {
+	start      time.Time
+	n          int
+	suppressed int
+}
+
+// sampleSummary is the number of entries suppressed for a key in an interval
+type sampleSummary struct {
+	sampleKey
+	suppressed int
+}
+
+type sampler struct {
+	interval   time.Duration
+	first      int
+	thereafter int
+
+	// expired, if set, is called with the summaries of intervals
+	// with suppressed entries once they have ended
+	expired func(summaries []sampleSummary)
+
+	mutex  sync.Mutex
+	counts map[sampleKey]*sampleCount
+	sweep  time.Time
+	timer  *time.Timer
+}
+
+func newSampler(cfg *SamplingConfig) *sampler {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	return &sampler{
+		interval:   interval,
+		first:      cfg.First,
+		thereafter: cfg.Thereafter,
+		counts:     map[sampleKey]*sampleCount{},
+	}
+}
+
+// sample reports whether an entry should be written. Summaries are returned
+// for the keys with suppressed entries whose interval has ended
+func (s *sampler) sample(logLevel LogLevel, msg string, now time.Time) (bool, []sampleSummary) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var summaries []sampleSummary
+	key := sampleKey{logLevel: logLevel, msg: msg}
+
+	// Drop the counts of ended intervals once per interval, keeping
+	// the map from growing with messages that are no longer logged
+	if !now.Before(s.sweep) {
+		summaries = s.ended(now)
+		s.sweep = now.Add(s.interval)
+	}
+
+	c := s.counts[key]
+	if c == nil || now.Sub(c.start) >= s.interval {
+		if c != nil && c.suppressed > 0 {
+			summaries = append(summaries, sampleSummary{key, c.suppressed})
+		}
+		c = &sampleCount{start: now}
+		s.counts[key] = c
+	}
+
+	c.n++
+	if c.n <= s.first || (s.thereafter > 0 && (c.n-s.first)%s.thereafter == 0) {
+		return true, summaries
+	}
+
+	c.suppressed++
+
+	// Report the suppressed entries when the interval has ended,
+	// even if the entry isn't logged again
+	if s.timer == nil && s.expired != nil {
+		s.timer = time.AfterFunc(c.start.Add(s.interval).Sub(now), s.expire)
+	}
+
+	return false, summaries
+}
+
+// ended drops the counts of the intervals ended at now, returning
+// summaries for those with suppressed entries
+func (s *sampler) ended(now time.Time) []sampleSummary {
+	var summaries []sampleSummary
+	for k, c := range s.counts {
+		if now.Sub(c.start) >= s.interval {
+			if c.suppressed > 0 {
+				summaries = append(summaries, sampleSummary{k, c.suppressed})
+			}
+			delete(s.counts, k)
+		}
+	}
+	return summaries
+}
+
+// expire reports the suppressed entries of ended intervals and waits
+// for the next interval with suppressed entries to end, if any
+func (s *sampler) expire() {
+	s.mutex.Lock()
+	now := time.Now()
+	summaries := s.ended(now)
+
+	s.timer = nil
+	var next time.Time
+	for _, c := range s.counts {
+		if end := c.start.Add(s.interval); c.suppressed > 0 && (next.IsZero() || end.Before(next)) {
+			next = end
+		}
+	}
+	if !next.IsZero() {
+		s.timer = time.AfterFunc(next.Sub(now), s.expire)
+	}
+	s.mutex.Unlock()
+
+	if len(summaries) > 0 {
+		s.expired(summaries)
+	}
+}
+
+// flush ends the intervals of all keys, returning
+// summaries for those with suppressed entries
+func (s *sampler) flush() []sampleSummary {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+
+	var summaries []sampleSummary
+	for k, c := range s.counts {
+		if c.suppressed > 0 {
+			summaries = append(summaries, sampleSummary{k, c.suppressed})
+		}
+	}
+	s.counts = map[sampleKey]*sampleCount{}
+
+	return summaries
+}
+
+// writeSummaries writes a summary entry per key with suppressed entries
+func (l *logger) writeSummaries(summaries []sampleSummary, fieldMapping FieldMapping, now time.Time) {
+	for _, summary := range summaries {
+		l.emit(summary.logLevel, summary.msg, now, l.GetFields(), []Field{Int(SuppressedField, summary.suppressed)}, fieldMapping, nil)
+	}
+}
+
+// flushSampled writes the summaries of entries suppressed by sampling, if any
+func (l *logger) flushSampled() {
+	if sampler, _ := l.sampler.Load().(*sampler); sampler != nil {
+		l.writeSummaries(sampler.flush(), l.GetFieldMapping(), time.Now())
+	}
+}
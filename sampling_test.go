@@ -0,0 +1,130 @@
+package wlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	s := newSampler(&SamplingConfig{Interval: time.Minute, First: 2, Thereafter: 3})
+	start := time.Now()
+
+	var kept []int
+	for i := 1; i <= 10; i++ {
+		if keep, summaries := s.sample(Wrn, "hot loop", start); keep {
+			kept = append(kept, i)
+		} else if len(summaries) != 0 {
+			t.Errorf("unexpected summaries %v", summaries)
+		}
+	}
+	if len(kept) != 4 || kept[0] != 1 || kept[1] != 2 || kept[2] != 5 || kept[3] != 8 {
+		t.Errorf("unexpected entries kept %v", kept)
+	}
+
+	// Other levels and messages are counted separately
+	if keep, _ := s.sample(Err, "hot loop", start); !keep {
+		t.Error("expected the first error to be kept")
+	}
+
+	keep, summaries := s.sample(Wrn, "hot loop", start.Add(time.Minute))
+	if !keep {
+		t.Error("expected the first entry of a new interval to be kept")
+	}
+	if len(summaries) != 1 || summaries[0].msg != "hot loop" || summaries[0].suppressed != 6 {
+		t.Errorf("unexpected summaries %v", summaries)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+	logger.SetSampling(&SamplingConfig{Interval: time.Hour, First: 1})
+
+	for i := 0; i < 5; i++ {
+		logger.Warning("hot loop")
+	}
+	logger.Info("later")
+
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", lines)
+	}
+
+	logger.SetSampling(nil)
+	lines = strings.Split(strings.TrimSpace(w.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", lines)
+	}
+	if !strings.Contains(lines[2], "hot loop") || !strings.Contains(lines[2], "suppressed: 4") {
+		t.Errorf("expected a summary entry, got %q", lines[2])
+	}
+	if stats := logger.Stats(); stats.Dropped != 4 || stats.Entries[Wrn] != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	for i := 0; i < 5; i++ {
+		logger.Warning("hot loop")
+	}
+	if n := strings.Count(w.String(), "\n"); n != 8 {
+		t.Errorf("expected sampling to be disabled, got %d lines", n)
+	}
+}
+
+func TestSamplingSummaryOnDisable(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+	logger.SetSampling(&SamplingConfig{Interval: time.Minute, First: 1})
+
+	for i := 0; i < 5; i++ {
+		logger.Warning("hot")
+	}
+	logger.SetSampling(nil)
+
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], "suppressed: 4") {
+		t.Errorf("expected a summary entry when sampling is disabled, got %q", lines)
+	}
+}
+
+func TestSamplingSummaryOnFatal(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+	logger.SetExitFunc(func(int) {})
+	logger.SetSampling(&SamplingConfig{Interval: time.Minute, First: 1})
+
+	for i := 0; i < 3; i++ {
+		logger.Warning("hot")
+	}
+	logger.Fatal("unrecoverable")
+
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[2], "hot") || !strings.Contains(lines[2], "suppressed: 2") {
+		t.Errorf("expected a summary entry on exit, got %q", lines)
+	}
+}
+
+func TestSamplingIntervalEnd(t *testing.T) {
+	w := &syncBuffer{}
+	logger := New(w, Nfo, false)
+	logger.SetSampling(&SamplingConfig{Interval: 20 * time.Millisecond, First: 1})
+
+	for i := 0; i < 5; i++ {
+		logger.Warning("hot")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(w.String(), "suppressed: 4") {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a summary entry once the interval ended, got %q", w.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The summary isn't written again when sampling is disabled
+	logger.SetSampling(nil)
+	if n := strings.Count(w.String(), "\n"); n != 2 {
+		t.Errorf("expected 2 lines, got %q", w.String())
+	}
+}
@@ -1,8 +1,11 @@
 package wlog
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"io"
+	"sync"
+	"time"
 )
 
 // scopedLogger implements interface Logger. This type is used when it is necessary
@@ -11,10 +14,27 @@ import (
 type scopedLogger struct {
 	logger *logger
 	fields Fields
+	typed  []Field
+
+	// parent is the scope this scope was created from, if any. Entries
+	// written through this scope are passed to the hooks of its parents
+	parent *scopedLogger
+
+	// buffer holds back entries if the scope was created with
+	// FingersCrossed. It's shared with scopes created from it
+	buffer *scopeBuffer
+
+	hookMutex sync.Mutex
+	hooks     []installedHook
+	hookID    uint64
 }
 
-// GetLogLevel implements Logger.GetLogLevel
+// GetLogLevel implements Logger.GetLogLevel. Scopes created with
+// FingersCrossed log at the level of their buffer if it's lower
 func (s *scopedLogger) GetLogLevel() LogLevel {
+	if s.buffer != nil && s.buffer.level.severity() < s.logger.logLevel.severity() {
+		return s.buffer.level
+	}
 	return s.logger.logLevel
 }
 
@@ -28,12 +48,28 @@ func (s *scopedLogger) GetFieldMapping() FieldMapping {
 	return s.logger.fieldMapping
 }
 
+// Tracef formats and logs a trace message
+func (s *scopedLogger) Tracef(format string, v ...interface{}) {
+	if Trc < s.GetLogLevel() {
+		return
+	}
+	s.logger.writeWithFields(Trc, fmt.Sprintf(format, v...), s.fields, s.typed, s.GetFieldMapping(), s)
+}
+
+// Trace logs a trace message
+func (s *scopedLogger) Trace(v ...interface{}) {
+	if Trc < s.GetLogLevel() {
+		return
+	}
+	s.logger.writeWithFields(Trc, fmt.Sprint(v...), s.fields, s.typed, s.GetFieldMapping(), s)
+}
+
 // Debugf formats and logs a debug message
 func (s *scopedLogger) Debugf(format string, v ...interface{}) {
 	if Dbg < s.GetLogLevel() {
 		return
 	}
-	s.logger.writeWithFields(Dbg, fmt.Sprintf(format, v...), s.fields, s.GetFieldMapping())
+	s.logger.writeWithFields(Dbg, fmt.Sprintf(format, v...), s.fields, s.typed, s.GetFieldMapping(), s)
 }
 
 // Debug logs a debug message
@@ -41,49 +77,115 @@ func (s *scopedLogger) Debug(v ...interface{}) {
 	if Dbg < s.GetLogLevel() {
 		return
 	}
-	s.logger.writeWithFields(Dbg, fmt.Sprint(v...), s.fields, s.GetFieldMapping())
+	s.logger.writeWithFields(Dbg, fmt.Sprint(v...), s.fields, s.typed, s.GetFieldMapping(), s)
 }
 
 // Infof formats and logs an informal message
 func (s *scopedLogger) Infof(format string, v ...interface{}) {
-	s.logger.writeWithFields(Nfo, fmt.Sprintf(format, v...), s.fields, s.GetFieldMapping())
+	s.logger.writeWithFields(Nfo, fmt.Sprintf(format, v...), s.fields, s.typed, s.GetFieldMapping(), s)
 }
 
 // Info logs an informal message
 func (s *scopedLogger) Info(v ...interface{}) {
-	s.logger.writeWithFields(Nfo, fmt.Sprint(v...), s.fields, s.GetFieldMapping())
+	s.logger.writeWithFields(Nfo, fmt.Sprint(v...), s.fields, s.typed, s.GetFieldMapping(), s)
 }
 
 // Warningf formats and logs a warning message
 func (s *scopedLogger) Warningf(format string, v ...interface{}) {
-	s.logger.writeWithFields(Wrn, fmt.Sprintf(format, v...), s.fields, s.GetFieldMapping())
+	s.logger.writeWithFields(Wrn, fmt.Sprintf(format, v...), s.fields, s.typed, s.GetFieldMapping(), s)
 }
 
 // Warning logs a warning message
 func (s *scopedLogger) Warning(v ...interface{}) {
-	s.logger.writeWithFields(Wrn, fmt.Sprint(v...), s.fields, s.GetFieldMapping())
+	s.logger.writeWithFields(Wrn, fmt.Sprint(v...), s.fields, s.typed, s.GetFieldMapping(), s)
 }
 
 // Errorf formats and logs an error message
 func (s *scopedLogger) Errorf(format string, v ...interface{}) {
-	s.logger.writeWithFields(Err, fmt.Sprintf(format, v...), s.fields, s.GetFieldMapping())
+	s.logger.writeWithFields(Err, fmt.Sprintf(format, v...), s.fields, s.typed, s.GetFieldMapping(), s)
 }
 
 // Error logs an error message
 func (s *scopedLogger) Error(v ...interface{}) {
-	s.logger.writeWithFields(Err, fmt.Sprint(v...), s.fields, s.GetFieldMapping())
+	s.logger.writeWithFields(Err, fmt.Sprint(v...), s.fields, s.typed, s.GetFieldMapping(), s)
+}
+
+// Panicf formats and logs a message at the Panic level and then panics with it
+func (s *scopedLogger) Panicf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	s.logger.writeWithFields(Pnc, msg, s.fields, s.typed, s.GetFieldMapping(), s)
+	panic(msg)
+}
+
+// Panic logs a message at the Panic level and then panics with it
+func (s *scopedLogger) Panic(v ...interface{}) {
+	msg := fmt.Sprint(v...)
+	s.logger.writeWithFields(Pnc, msg, s.fields, s.typed, s.GetFieldMapping(), s)
+	panic(msg)
 }
 
 // Fatalf formats and logs an unrecoverable error message
 func (s *scopedLogger) Fatalf(format string, v ...interface{}) {
-	s.logger.writeWithFields(Ftl, fmt.Sprintf(format, v...), s.fields, s.GetFieldMapping())
-	os.Exit(1)
+	s.logger.writeWithFields(Ftl, fmt.Sprintf(format, v...), s.fields, s.typed, s.GetFieldMapping(), s)
+	s.logger.exit(0)
 }
 
 // Fatal logs an unrecoverable error message
 func (s *scopedLogger) Fatal(v ...interface{}) {
-	s.logger.writeWithFields(Ftl, fmt.Sprint(v...), s.fields, s.GetFieldMapping())
-	os.Exit(1)
+	s.logger.writeWithFields(Ftl, fmt.Sprint(v...), s.fields, s.typed, s.GetFieldMapping(), s)
+	s.logger.exit(0)
+}
+
+// DebugCtx logs a debug message with the registered context keys of ctx as fields
+func (s *scopedLogger) DebugCtx(ctx context.Context, v ...interface{}) {
+	if Dbg < s.GetLogLevel() {
+		return
+	}
+	s.writeCtx(ctx, Dbg, fmt.Sprint(v...))
+}
+
+// InfoCtx logs an informal message with the registered context keys of ctx as fields
+func (s *scopedLogger) InfoCtx(ctx context.Context, v ...interface{}) {
+	s.writeCtx(ctx, Nfo, fmt.Sprint(v...))
+}
+
+// WarningCtx logs a warning message with the registered context keys of ctx as fields
+func (s *scopedLogger) WarningCtx(ctx context.Context, v ...interface{}) {
+	s.writeCtx(ctx, Wrn, fmt.Sprint(v...))
+}
+
+// ErrorCtx logs an error message with the registered context keys of ctx as fields
+func (s *scopedLogger) ErrorCtx(ctx context.Context, v ...interface{}) {
+	s.writeCtx(ctx, Err, fmt.Sprint(v...))
+}
+
+func (s *scopedLogger) writeCtx(ctx context.Context, logLevel LogLevel, msg string) {
+	if logLevel.severity() < s.GetLogLevel().severity() {
+		return
+	}
+	s.logger.writeWithFields(logLevel, msg, s.fields, contextFields(ctx, s.typed, s.GetFieldMapping()), s.GetFieldMapping(), s)
+}
+
+// Writer returns an io.WriteCloser logging each line written to it as an
+// entry at logLevel with the fields of this scope
+func (s *scopedLogger) Writer(logLevel LogLevel) io.WriteCloser {
+	return newLineWriter(s, logLevel)
+}
+
+// writeEntry implements entryWriter.writeEntry
+func (s *scopedLogger) writeEntry(logLevel LogLevel, msg string, timestamp time.Time, typed []Field) {
+	if logLevel.severity() < s.GetLogLevel().severity() {
+		return
+	}
+	if len(typed) > 0 {
+		typed = append(append(make([]Field, 0, len(s.typed)+len(typed)), s.typed...), typed...)
+	} else {
+		typed = s.typed
+	}
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	s.logger.writeAt(logLevel, msg, timestamp, s.fields, typed, s.GetFieldMapping(), s)
 }
 
 // GetFormatter gets the writer of the logger
@@ -109,5 +211,74 @@ func (s *scopedLogger) WithScope(fields Fields) Logger {
 		scopeFields[k] = v
 	}
 
-	return &scopedLogger{s.logger, scopeFields}
+	return &scopedLogger{logger: s.logger, parent: s, buffer: s.buffer, fields: scopeFields, typed: s.typed}
+}
+
+// With returns a new instance of Logger based on this Logger with
+// the typed fields added to it's scope. Any fields from this Logger
+// will be included
+func (s *scopedLogger) With(fields ...Field) Logger {
+	typed := make([]Field, 0, len(s.typed)+len(fields))
+	typed = append(typed, s.typed...)
+	typed = append(typed, fields...)
+
+	// The fields map of a scope is never modified and can be shared
+	return &scopedLogger{logger: s.logger, parent: s, buffer: s.buffer, fields: s.fields, typed: typed}
+}
+
+// WithContext returns a new instance of Logger based on this Logger
+// with the values of any registered context keys present in ctx
+// added as fields
+func (s *scopedLogger) WithContext(ctx context.Context) Logger {
+	return &scopedLogger{logger: s.logger, parent: s, buffer: s.buffer, fields: s.fields, typed: contextFields(ctx, s.typed, s.GetFieldMapping())}
+}
+
+// InstallHook installs a hook that will be called for log entries at
+// exactly logLevel written through this scope or scopes created from it.
+// The returned function removes the hook
+func (s *scopedLogger) InstallHook(logLevel LogLevel, hook HookFunc) func() {
+	return s.installHook(installedHook{logLevel: logLevel, exact: true, hook: hookFunc(hook)})
+}
+
+// AddHook installs a hook that will be called for log entries at logLevel
+// and above written through this scope or scopes created from it. The hooks
+// of the root logger are called as well. The returned function removes the hook
+func (s *scopedLogger) AddHook(logLevel LogLevel, hook Hook) func() {
+	return s.installHook(installedHook{logLevel: logLevel, hook: hook})
+}
+
+func (s *scopedLogger) installHook(h installedHook) func() {
+	s.hookMutex.Lock()
+	defer s.hookMutex.Unlock()
+
+	s.hookID++
+	h.id = s.hookID
+	s.hooks = append(s.hooks, h)
+
+	return func() {
+		s.hookMutex.Lock()
+		defer s.hookMutex.Unlock()
+		s.hooks = removeHook(s.hooks, h.id)
+	}
+}
+
+// appendHooks returns hooks with the hooks of this scope and its parents
+// added, innermost scope first. hooks is not modified
+func (s *scopedLogger) appendHooks(hooks []installedHook) []installedHook {
+	copied := false
+	for scope := s; scope != nil; scope = scope.parent {
+		scope.hookMutex.Lock()
+		scopeHooks := scope.hooks
+		scope.hookMutex.Unlock()
+
+		if len(scopeHooks) == 0 {
+			continue
+		}
+		if !copied {
+			hooks = append(make([]installedHook, 0, len(hooks)+len(scopeHooks)), hooks...)
+			copied = true
+		}
+		hooks = append(hooks, scopeHooks...)
+	}
+	return hooks
 }
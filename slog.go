@@ -0,0 +1,278 @@
+//go:build go1.21
+// +build go1.21
+
+package wlog
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// slogHandler implements slog.Handler on top of a wlog Logger
+type slogHandler struct {
+	logger Logger
+
+	// groups holds the groups opened with WithGroup and groupFields the
+	// fields added with WithAttrs while the group at the same index was
+	// the innermost one
+	groups      []string
+	groupFields []Fields
+}
+
+// NewSlogHandler returns a slog.Handler writing records to logger. Levels
+// are mapped to the closest LogLevel, attributes are added as Fields and
+// groups become nested Fields
+func NewSlogHandler(logger Logger) slog.Handler {
+	return &slogHandler{logger: logger}
+}
+
+// Enabled implements slog.Handler.Enabled
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return fromSlogLevel(level).severity() >= h.logger.GetLogLevel().severity()
+}
+
+// Handle implements slog.Handler.Handle
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := Fields{}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(fields, a)
+		return true
+	})
+
+	// Nest the fields in the open groups, innermost first
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		group := Fields{}
+		for k, v := range h.groupFields[i] {
+			group[k] = v
+		}
+		for k, v := range fields {
+			group[k] = v
+		}
+
+		fields = Fields{}
+		if len(group) > 0 {
+			fields[h.groups[i]] = group
+		}
+	}
+
+	logger := h.logger
+	if len(fields) > 0 {
+		logger = logger.WithScope(fields)
+	}
+
+	// Keep the time of the record when logging to this package
+	if ew, ok := logger.(entryWriter); ok {
+		ew.writeEntry(fromSlogLevel(r.Level), r.Message, r.Time, nil)
+	} else {
+		logAt(logger, fromSlogLevel(r.Level), r.Message)
+	}
+
+	return nil
+}
+
+// WithAttrs implements slog.Handler.WithAttrs
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	if len(h.groups) == 0 {
+		fields := Fields{}
+		for _, a := range attrs {
+			addAttr(fields, a)
+		}
+		return &slogHandler{logger: h.logger.WithScope(fields)}
+	}
+
+	// Add the attributes to the innermost group
+	last := len(h.groupFields) - 1
+	fields := Fields{}
+	for k, v := range h.groupFields[last] {
+		fields[k] = v
+	}
+	for _, a := range attrs {
+		addAttr(fields, a)
+	}
+
+	groupFields := append([]Fields(nil), h.groupFields...)
+	groupFields[last] = fields
+
+	return &slogHandler{logger: h.logger, groups: h.groups, groupFields: groupFields}
+}
+
+// WithGroup implements slog.Handler.WithGroup
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &slogHandler{
+		logger:      h.logger,
+		groups:      append(h.groups[:len(h.groups):len(h.groups)], name),
+		groupFields: append(h.groupFields[:len(h.groupFields):len(h.groupFields)], nil),
+	}
+}
+
+// addAttr adds a resolved attribute to fields. Groups are added as
+// nested Fields, or inlined if they don't have a key
+func addAttr(fields Fields, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		fields[a.Key] = slogValue(a.Value)
+		return
+	}
+
+	attrs := a.Value.Group()
+	if len(attrs) == 0 {
+		return
+	}
+
+	group := fields
+	if a.Key != "" {
+		group = Fields{}
+		fields[a.Key] = group
+	}
+	for _, ga := range attrs {
+		addAttr(group, ga)
+	}
+}
+
+func slogValue(v slog.Value) interface{} {
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String()
+	case slog.KindInt64:
+		return v.Int64()
+	case slog.KindUint64:
+		return v.Uint64()
+	case slog.KindFloat64:
+		return v.Float64()
+	case slog.KindBool:
+		return v.Bool()
+	case slog.KindDuration:
+		return v.Duration()
+	case slog.KindTime:
+		return v.Time()
+	}
+
+	if err, ok := v.Any().(error); ok {
+		return errorString(err)
+	}
+	return v.Any()
+}
+
+func fromSlogLevel(level slog.Level) LogLevel {
+	switch {
+	case level < slog.LevelDebug:
+		return Trc
+	case level < slog.LevelInfo:
+		return Dbg
+	case level < slog.LevelWarn:
+		return Nfo
+	case level < slog.LevelError:
+		return Wrn
+	}
+	return Err
+}
+
+func toSlogLevel(logLevel LogLevel) slog.Level {
+	switch logLevel {
+	case Trc:
+		return slog.LevelDebug - 4
+	case Dbg:
+		return slog.LevelDebug
+	case Nfo:
+		return slog.LevelInfo
+	case Wrn:
+		return slog.LevelWarn
+	case Err:
+		return slog.LevelError
+	case Pnc:
+		return slog.LevelError + 4
+	}
+	return slog.LevelError + 8
+}
+
+// NewSlogLogger returns a MutableLogger writing entries to handler. Fields
+// are passed on as attributes and LogMarshaler values as groups. The
+// formatter, writer and standard output settings of the returned logger
+// are not used
+func NewSlogLogger(handler slog.Handler) MutableLogger {
+	l := newLogger(nil, Trc, false)
+	l.handler = slogOutput{handler}
+	return l
+}
+
+// slogOutput implements entryHandler by passing entries on to a
+// slog.Handler
+type slogOutput struct {
+	handler slog.Handler
+}
+
+// handleEntry implements entryHandler.handleEntry
+func (o slogOutput) handleEntry(logLevel LogLevel, msg string, timestamp time.Time, fields Fields, typed []Field) error {
+	ctx := context.Background()
+	level := toSlogLevel(logLevel)
+	if !o.handler.Enabled(ctx, level) {
+		return nil
+	}
+
+	enc := &attrEncoder{}
+	writeFields(enc, fields, typed)
+
+	r := slog.NewRecord(timestamp, level, msg, 0)
+	r.AddAttrs(enc.attrs...)
+
+	return o.handler.Handle(ctx, r)
+}
+
+// attrEncoder implements FieldEncoder by collecting slog attributes
+type attrEncoder struct {
+	attrs []slog.Attr
+}
+
+func (e *attrEncoder) AddString(key, value string) {
+	e.attrs = append(e.attrs, slog.String(key, value))
+}
+
+func (e *attrEncoder) AddInt64(key string, value int64) {
+	e.attrs = append(e.attrs, slog.Int64(key, value))
+}
+
+func (e *attrEncoder) AddFloat64(key string, value float64) {
+	e.attrs = append(e.attrs, slog.Float64(key, value))
+}
+
+func (e *attrEncoder) AddBool(key string, value bool) {
+	e.attrs = append(e.attrs, slog.Bool(key, value))
+}
+
+func (e *attrEncoder) AddDuration(key string, value time.Duration) {
+	e.attrs = append(e.attrs, slog.Duration(key, value))
+}
+
+func (e *attrEncoder) AddTime(key string, value time.Time) {
+	e.attrs = append(e.attrs, slog.Time(key, value))
+}
+
+func (e *attrEncoder) AddAny(key string, value interface{}) {
+	switch v := value.(type) {
+	case LazyValue:
+		e.AddAny(key, v())
+	case LogMarshaler:
+		e.AddObject(key, v)
+	default:
+		e.attrs = append(e.attrs, slog.Any(key, v))
+	}
+}
+
+func (e *attrEncoder) AddObject(key string, value LogMarshaler) {
+	nested := &attrEncoder{}
+	value.MarshalLog(nested)
+	e.attrs = append(e.attrs, slog.Attr{Key: key, Value: slog.GroupValue(nested.attrs...)})
+}
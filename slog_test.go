@@ -0,0 +1,122 @@
+//go:build go1.21
+// +build go1.21
+
+package wlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlogHandler(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+	logger.SetFormatter(JSONFormatter{})
+
+	sl := slog.New(NewSlogHandler(logger)).With("service", "api")
+
+	sl.Debug("filtered")
+	if w.Len() > 0 {
+		t.Fatalf("unexpected log output %q", w.String())
+	}
+
+	sl.WithGroup("request").With("method", "GET").Warn("slow", "elapsed", 3, slog.Group("user", "id", 7))
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(w.Bytes(), &got); err != nil {
+		t.Fatalf("failed to decode %q: %v", w.String(), err)
+	}
+
+	if got["level"] != "Warning" || got["message"] != "slow" || got["service"] != "api" {
+		t.Errorf("unexpected entry %v", got)
+	}
+
+	request, _ := got["request"].(map[string]interface{})
+	user, _ := request["user"].(map[string]interface{})
+	if request["method"] != "GET" || request["elapsed"] != float64(3) || user["id"] != float64(7) {
+		t.Errorf("unexpected nested fields %v", got["request"])
+	}
+
+	w.Reset()
+	logger.SetFormatter(TextFormatter{})
+	sl.Error("failed", slog.Group("user", "id", 7))
+	if got := w.String(); !strings.Contains(got, "ERR failed") || !strings.Contains(got, "user: {id: 7}") {
+		t.Errorf("unexpected log output %q", got)
+	}
+}
+
+func TestSlogHandlerRecordTime(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+	logger.SetFormatter(JSONFormatter{})
+
+	timestamp := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := slog.NewRecord(timestamp, slog.LevelInfo, "replayed", 0)
+	if err := NewSlogHandler(logger.WithScope(Fields{"a": 1})).Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(w.String(), "2020-01-02 03:04:05") {
+		t.Errorf("expected the time of the record, got %q", w.String())
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	w := &bytes.Buffer{}
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelInfo})
+
+	logger := NewSlogLogger(handler)
+	logger.SetFields(Fields{"service": "api"})
+
+	logger.Debug("filtered")
+	if w.Len() > 0 {
+		t.Fatalf("unexpected log output %q", w.String())
+	}
+
+	logger.With(Int("attempt", 2), Object("user", Fields{"id": 7})).Warning("retrying")
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(w.Bytes(), &got); err != nil {
+		t.Fatalf("failed to decode %q: %v", w.String(), err)
+	}
+
+	user, _ := got["user"].(map[string]interface{})
+	if got["level"] != "WARN" || got["msg"] != "retrying" || got["service"] != "api" || got["attempt"] != float64(2) || user["id"] != float64(7) {
+		t.Errorf("unexpected entry %v", got)
+	}
+}
+
+// loopbackHandler logs every record it handles back to logger once
+type loopbackHandler struct {
+	slog.Handler
+	logger Logger
+}
+
+func (h loopbackHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Message == "outer" {
+		h.logger.Info("inner")
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func TestSlogLoggerOutput(t *testing.T) {
+	w := &bytes.Buffer{}
+	h := &loopbackHandler{Handler: slog.NewTextHandler(w, nil)}
+
+	logger := NewSlogLogger(h)
+	h.logger = logger
+
+	// Replacing the formatter doesn't disconnect the handler
+	logger.SetFormatter(JSONFormatter{})
+	logger.Configure(&Config{LogLevel: Nfo})
+
+	logger.Info("outer")
+	if !strings.Contains(w.String(), "msg=inner") || !strings.Contains(w.String(), "msg=outer") {
+		t.Errorf("expected both entries to reach the handler, got %q", w.String())
+	}
+}
@@ -0,0 +1,113 @@
+package wlog
+
+import (
+	"expvar"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// levelCount is the number of log levels from Trc to Pnc, the
+// level with the highest value
+const levelCount = int(Pnc-Trc) + 1
+
+// counters holds the counters of a logger. It's allocated separately
+// from the logger to guarantee 64-bit alignment for atomic access
+type counters struct {
+	entries [levelCount]uint64
+	dropped uint64
+	failed  uint64
+}
+
+// Stats holds the counters of a logger
+type Stats struct {
+	// Entries is the number of entries written per log level
+	Entries map[LogLevel]uint64
+
+	// Dropped is the number of entries discarded by the logger
+	// rather than written, e.g. by sampling or when
+	// collapsing duplicates
+	Dropped uint64
+
+	// Failed is the number of writes to the io.Writer or standard
+	// output of the logger that returned an error
+	Failed uint64
+}
+
+// Stats returns the counters of the logger. Scoped loggers
+// count towards the logger they were created from
+func (l *logger) Stats() Stats {
+	stats := Stats{
+		Entries: make(map[LogLevel]uint64, levelCount),
+		Dropped: atomic.LoadUint64(&l.counters.dropped),
+		Failed:  atomic.LoadUint64(&l.counters.failed),
+	}
+	for i := range l.counters.entries {
+		stats.Entries[Trc+LogLevel(i)] = atomic.LoadUint64(&l.counters.entries[i])
+	}
+	return stats
+}
+
+func (c *counters) countEntry(logLevel LogLevel) {
+	if logLevel >= Trc && logLevel <= Pnc {
+		atomic.AddUint64(&c.entries[logLevel-Trc], 1)
+	}
+}
+
+// PublishStats publishes the counters of logger as an expvar variable
+// with the given name. Like expvar.Publish it panics if the name is
+// already in use
+func PublishStats(name string, logger MutableLogger) {
+	expvar.Publish(name, statsVar(logger))
+}
+
+// statsVar returns an expvar.Var reporting the counters of logger
+func statsVar(logger MutableLogger) expvar.Func {
+	return expvar.Func(func() interface{} {
+		stats := logger.Stats()
+
+		entries := make(map[string]uint64, len(stats.Entries))
+		for logLevel, n := range stats.Entries {
+			entries[levelLabel(logLevel)] = n
+		}
+
+		return map[string]interface{}{
+			"entries": entries,
+			"dropped": stats.Dropped,
+			"failed":  stats.Failed,
+		}
+	})
+}
+
+// StatsHandler returns an http.Handler serving the counters of
+// logger in the Prometheus text exposition format
+func StatsHandler(logger MutableLogger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stats := logger.Stats()
+
+		var b strings.Builder
+		b.WriteString("# HELP wlog_entries_total Number of log entries written per level.\n")
+		b.WriteString("# TYPE wlog_entries_total counter\n")
+		for _, logLevel := range levels {
+			b.WriteString(`wlog_entries_total{level="` + levelLabel(logLevel) + `"} `)
+			b.WriteString(strconv.FormatUint(stats.Entries[logLevel], 10) + "\n")
+		}
+
+		b.WriteString("# HELP wlog_dropped_entries_total Number of log entries discarded rather than written.\n")
+		b.WriteString("# TYPE wlog_dropped_entries_total counter\n")
+		b.WriteString("wlog_dropped_entries_total " + strconv.FormatUint(stats.Dropped, 10) + "\n")
+
+		b.WriteString("# HELP wlog_failed_writes_total Number of writes of log entries that failed.\n")
+		b.WriteString("# TYPE wlog_failed_writes_total counter\n")
+		b.WriteString("wlog_failed_writes_total " + strconv.FormatUint(stats.Failed, 10) + "\n")
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		io.WriteString(w, b.String())
+	})
+}
+
+func levelLabel(logLevel LogLevel) string {
+	return strings.ToLower(logLevel.String())
+}
@@ -0,0 +1,64 @@
+package wlog
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestStats(t *testing.T) {
+	logger := New(failingWriter{}, Dbg, false)
+
+	logger.Trace("below the log level")
+	logger.Debug("debug")
+	scope := logger.WithScope(Fields{"tenant": "acme"})
+	scope.Error("error")
+	scope.Error("error")
+
+	stats := logger.Stats()
+	if stats.Entries[Trc] != 0 || stats.Entries[Dbg] != 1 || stats.Entries[Err] != 2 {
+		t.Errorf("unexpected entry counts %v", stats.Entries)
+	}
+	if stats.Failed != 3 {
+		t.Errorf("expected 3 failed writes, got %d", stats.Failed)
+	}
+
+	var published struct {
+		Entries map[string]uint64
+		Failed  uint64
+	}
+	if err := json.Unmarshal([]byte(statsVar(logger).String()), &published); err != nil {
+		t.Fatal(err)
+	}
+	if published.Entries["error"] != 2 || published.Failed != 3 {
+		t.Errorf("unexpected published stats %+v", published)
+	}
+
+	rec := httptest.NewRecorder()
+	StatsHandler(logger).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE wlog_entries_total counter\n",
+		`wlog_entries_total{level="debug"} 1` + "\n",
+		`wlog_entries_total{level="error"} 2` + "\n",
+		`wlog_entries_total{level="fatal"} 0` + "\n",
+		"wlog_dropped_entries_total 0\n",
+		"wlog_failed_writes_total 3\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in\n%s", want, body)
+		}
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+}
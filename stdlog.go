@@ -0,0 +1,118 @@
+package wlog
+
+import (
+	"log"
+	"strings"
+)
+
+// stdLogWriter implements io.Writer for the standard library log package.
+// Each line written is stripped of the prefix and flags of the log.Logger
+// it belongs to and logged as an entry
+type stdLogWriter struct {
+	logger   Logger
+	logLevel LogLevel
+
+	// std is the log.Logger writing to the writer, and flags and prefix
+	// its settings when the writer was created
+	std    stdLogger
+	flags  int
+	prefix string
+}
+
+// stdLogger is implemented by *log.Logger and by stdLogDefault
+type stdLogger interface {
+	Flags() int
+	Prefix() string
+}
+
+// stdLogDefault implements stdLogger for the standard logger
+type stdLogDefault struct{}
+
+func (stdLogDefault) Flags() int {
+	return log.Flags()
+}
+
+func (stdLogDefault) Prefix() string {
+	return log.Prefix()
+}
+
+// Write implements io.Writer.Write. A log.Logger writes each entry
+// in a single call, which may span several lines
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	flags, prefix := w.header()
+	msg := strings.TrimSuffix(strip(string(p), flags, prefix), "\n")
+	logAt(w.logger, w.logLevel, msg)
+	return len(p), nil
+}
+
+// strip removes the prefix, date, time and file information
+// added by a log.Logger configured with flags and prefix
+func strip(line string, flags int, prefix string) string {
+	if flags&log.Lmsgprefix == 0 {
+		line = strings.TrimPrefix(line, prefix)
+	}
+
+	if flags&log.Ldate != 0 {
+		// 2009/01/23
+		line = skipField(line, 10)
+	}
+
+	if flags&(log.Ltime|log.Lmicroseconds) != 0 {
+		// 01:23:23 or 01:23:23.123123
+		n := 8
+		if flags&log.Lmicroseconds != 0 {
+			n += 7
+		}
+		line = skipField(line, n)
+	}
+
+	if flags&(log.Lshortfile|log.Llongfile) != 0 {
+		// file.go:23:
+		if i := strings.Index(line, ": "); i >= 0 {
+			line = line[i+2:]
+		}
+	}
+
+	if flags&log.Lmsgprefix != 0 {
+		line = strings.TrimPrefix(line, prefix)
+	}
+
+	return line
+}
+
+// skipField skips n characters and the space that follows
+func skipField(line string, n int) string {
+	if len(line) <= n || line[n] != ' ' {
+		return line
+	}
+	return line[n+1:]
+}
+
+// RedirectStdLog redirects the output of the standard library log package to
+// logger. Each entry is logged at logLevel after removing the prefix and the
+// date, time and file information of the standard logger. The returned
+// function restores the previous output of the log package
+func RedirectStdLog(logger Logger, logLevel LogLevel) func() {
+	output := log.Writer()
+
+	log.SetOutput(&stdLogWriter{
+		logger:   logger,
+		logLevel: logLevel,
+		std:      stdLogDefault{},
+		flags:    log.Flags(),
+		prefix:   log.Prefix(),
+	})
+
+	return func() {
+		log.SetOutput(output)
+	}
+}
+
+// NewStdLogger returns a *log.Logger writing each entry to logger at logLevel.
+// It can be used with APIs that require a *log.Logger, e.g. http.Server.ErrorLog
+func NewStdLogger(logger Logger, logLevel LogLevel) *log.Logger {
+	w := &stdLogWriter{logger: logger, logLevel: logLevel}
+	std := log.New(w, "", 0)
+	w.std = std
+	return std
+}
@@ -0,0 +1,10 @@
+//go:build go1.21
+// +build go1.21
+
+package wlog
+
+// header returns the current flags and prefix of the log.Logger. They
+// can be read while it writes since Go 1.21
+func (w *stdLogWriter) header() (int, string) {
+	return w.std.Flags(), w.std.Prefix()
+}
@@ -0,0 +1,39 @@
+//go:build go1.21
+// +build go1.21
+
+package wlog
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestStdLogFlagsChanged(t *testing.T) {
+	flags, prefix := log.Flags(), log.Prefix()
+	defer log.SetFlags(flags)
+	defer log.SetPrefix(prefix)
+
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+
+	log.SetFlags(0)
+	log.SetPrefix("")
+	restore := RedirectStdLog(logger, Wrn)
+	defer restore()
+
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	log.SetPrefix("[app] ")
+	log.Print("connection reset")
+
+	std := NewStdLogger(logger, Err)
+	std.SetFlags(log.Ltime)
+	std.SetPrefix("http: ")
+	std.Print("TLS handshake error")
+
+	got := w.String()
+	if !strings.Contains(got, " WRN connection reset\n") || !strings.Contains(got, " ERR TLS handshake error\n") {
+		t.Errorf("unexpected log output %q", got)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !go1.21
+// +build !go1.21
+
+package wlog
+
+// header returns the flags and prefix of the log.Logger when the writer
+// was created. Before Go 1.21 a log.Logger holds its lock while writing,
+// so reading its current settings would deadlock
+func (w *stdLogWriter) header() (int, string) {
+	return w.flags, w.prefix
+}
@@ -0,0 +1,57 @@
+package wlog
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestRedirectStdLog(t *testing.T) {
+	flags, prefix := log.Flags(), log.Prefix()
+	defer log.SetFlags(flags)
+	defer log.SetPrefix(prefix)
+
+	tests := []struct {
+		flags  int
+		prefix string
+	}{
+		{log.LstdFlags, ""},
+		{log.LstdFlags | log.Lmicroseconds | log.Lshortfile, "[app] "},
+		{log.Ldate | log.Llongfile | log.Lmsgprefix, "app: "},
+		{0, ""},
+	}
+	for _, tt := range tests {
+		log.SetFlags(tt.flags)
+		log.SetPrefix(tt.prefix)
+
+		w := &bytes.Buffer{}
+		logger := New(w, Nfo, false)
+
+		restore := RedirectStdLog(logger.WithScope(Fields{"source": "stdlog"}), Wrn)
+		log.Printf("connection reset: %s", "peer")
+		restore()
+
+		got := w.String()
+		if !strings.Contains(got, " WRN connection reset: peer [source: stdlog]\n") {
+			t.Errorf("flags %d, prefix %q: unexpected log output %q", tt.flags, tt.prefix, got)
+		}
+	}
+}
+
+func TestNewStdLogger(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+
+	NewStdLogger(logger, Err).Println("http: TLS handshake error")
+
+	if got := w.String(); !strings.Contains(got, " ERR http: TLS handshake error\n") {
+		t.Errorf("unexpected log output %q", got)
+	}
+
+	w.Reset()
+	NewStdLogger(logger, Dbg).Println("filtered")
+	if w.Len() > 0 {
+		t.Errorf("unexpected log output %q", w.String())
+	}
+}
@@ -0,0 +1,420 @@
+package wlog
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SyslogFormat is the format of the messages sent by a SyslogHook
+type SyslogFormat int
+
+// The syslog formats available
+const (
+	// RFC5424 is the format of RFC 5424, with fields as structured data
+	RFC5424 SyslogFormat = iota
+	// RFC3164 is the BSD syslog format, with fields appended to the message
+	RFC3164
+)
+
+// SyslogFacility is a syslog facility
+type SyslogFacility int
+
+// The syslog facilities available
+const (
+	FacilityKern SyslogFacility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLPR
+	FacilityNews
+	FacilityUUCP
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFTP
+	_
+	_
+	_
+	_
+	FacilityLocal0
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// DefaultSyslogSDID is the SD-ID of the structured data element holding
+// the fields of an entry unless SyslogConfig.SDID is set. 32473 is the
+// private enterprise number reserved for documentation by RFC 5612
+const DefaultSyslogSDID = "fields@32473"
+
+// SyslogConfig configures a SyslogHook
+type SyslogConfig struct {
+	// Network and Address are the network and address of the syslog
+	// server as passed to net.Dial, e.g. "udp" and "localhost:514".
+	// Network may be udp, tcp, unix or unixgram. If both are empty the
+	// local syslog socket is used
+	Network string
+	Address string
+
+	// Format is the format of the messages. Defaults to RFC5424
+	Format SyslogFormat
+
+	// Facility is the facility of the messages. Defaults to FacilityUser
+	// since user processes may not use FacilityKern
+	Facility SyslogFacility
+
+	// AppName, Hostname and ProcID identify the sender. They default to
+	// the name of the executable, the host name and the process id
+	AppName  string
+	Hostname string
+	ProcID   string
+
+	// SDID is the SD-ID of the structured data element holding the
+	// fields of RFC 5424 messages. Defaults to DefaultSyslogSDID
+	SDID string
+
+	// Timeout is the timeout for connecting and writing.
+	// Defaults to 10 seconds
+	Timeout time.Duration
+
+	// MinBackoff and MaxBackoff bound the time waited between attempts
+	// to reconnect. They default to 100 milliseconds and 30 seconds
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// SyslogHook is a Hook sending entries to a syslog server. Log levels are
+// mapped to syslog severities. Messages are framed with octet counting on
+// TCP and terminated by a newline on unix stream sockets. If the connection
+// is lost the hook reconnects, backing off exponentially while it fails.
+// Entries fired while waiting to reconnect are dropped. Wrap the hook with
+// NewAsyncHook to avoid blocking the writer of an entry
+type SyslogHook struct {
+	// Accessed atomically and kept first for alignment
+	dropped uint64
+
+	network    string
+	address    string
+	format     SyslogFormat
+	facility   SyslogFacility
+	appName    string
+	hostname   string
+	procID     string
+	sdID       string
+	timeout    time.Duration
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	mutex   sync.Mutex
+	conn    net.Conn
+	backoff time.Duration
+	retryAt time.Time
+}
+
+// localSyslogSockets are the paths tried for the local syslog socket
+var localSyslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// NewSyslogHook returns a SyslogHook connected to the syslog server
+// configured by cfg. cfg may be nil, in which case the local syslog
+// socket is used with the default settings
+func NewSyslogHook(cfg *SyslogConfig) (*SyslogHook, error) {
+	if cfg == nil {
+		cfg = &SyslogConfig{}
+	}
+
+	h := &SyslogHook{
+		network:    cfg.Network,
+		address:    cfg.Address,
+		format:     cfg.Format,
+		facility:   cfg.Facility,
+		appName:    cfg.AppName,
+		hostname:   cfg.Hostname,
+		procID:     cfg.ProcID,
+		sdID:       cfg.SDID,
+		timeout:    cfg.Timeout,
+		minBackoff: cfg.MinBackoff,
+		maxBackoff: cfg.MaxBackoff,
+	}
+
+	if h.facility == FacilityKern {
+		h.facility = FacilityUser
+	}
+	if h.appName == "" {
+		h.appName = filepath.Base(os.Args[0])
+	}
+	if h.hostname == "" {
+		h.hostname, _ = os.Hostname()
+	}
+	if h.procID == "" {
+		h.procID = strconv.Itoa(os.Getpid())
+	}
+	if h.sdID == "" {
+		h.sdID = DefaultSyslogSDID
+	}
+	if h.timeout <= 0 {
+		h.timeout = 10 * time.Second
+	}
+	if h.minBackoff <= 0 {
+		h.minBackoff = 100 * time.Millisecond
+	}
+	if h.maxBackoff <= 0 {
+		h.maxBackoff = 30 * time.Second
+	}
+	if h.maxBackoff < h.minBackoff {
+		h.maxBackoff = h.minBackoff
+	}
+
+	conn, err := h.dial()
+	if err != nil {
+		return nil, err
+	}
+	h.conn = conn
+
+	return h, nil
+}
+
+// Fire implements Hook.Fire by sending entry to the syslog server
+func (h *SyslogHook) Fire(entry *Entry) error {
+	var msg string
+	if h.format == RFC3164 {
+		msg = h.formatRFC3164(entry)
+	} else {
+		msg = h.formatRFC5424(entry)
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	// Retry once on a new connection if the write fails
+	for attempt := 0; attempt < 2; attempt++ {
+		if h.conn == nil {
+			if time.Now().Before(h.retryAt) {
+				atomic.AddUint64(&h.dropped, 1)
+				return nil
+			}
+			if err := h.reconnect(); err != nil {
+				atomic.AddUint64(&h.dropped, 1)
+				return err
+			}
+		}
+
+		if err := h.write(msg); err == nil {
+			return nil
+		}
+
+		h.conn.Close()
+		h.conn = nil
+	}
+
+	atomic.AddUint64(&h.dropped, 1)
+	return errors.New("syslog: could not send entry")
+}
+
+// Dropped returns the number of entries dropped since
+// the syslog server couldn't be reached
+func (h *SyslogHook) Dropped() uint64 {
+	return atomic.LoadUint64(&h.dropped)
+}
+
+// Close closes the connection to the syslog server
+func (h *SyslogHook) Close() error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.conn == nil {
+		return nil
+	}
+	err := h.conn.Close()
+	h.conn = nil
+	return err
+}
+
+func (h *SyslogHook) dial() (net.Conn, error) {
+	if h.network != "" || h.address != "" {
+		return net.DialTimeout(h.network, h.address, h.timeout)
+	}
+
+	for _, path := range localSyslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := net.DialTimeout(network, path, h.timeout); err == nil {
+				h.network = network
+				h.address = path
+				return conn, nil
+			}
+		}
+	}
+	return nil, errors.New("syslog: could not connect to the local syslog socket")
+}
+
+// reconnect dials the syslog server, doubling the time until the
+// next attempt if it fails
+func (h *SyslogHook) reconnect() error {
+	conn, err := h.dial()
+	if err != nil {
+		h.backoff *= 2
+		if h.backoff < h.minBackoff {
+			h.backoff = h.minBackoff
+		}
+		if h.backoff > h.maxBackoff {
+			h.backoff = h.maxBackoff
+		}
+		h.retryAt = time.Now().Add(h.backoff)
+		return fmt.Errorf("syslog: could not reconnect, retrying in %v: %v", h.backoff, err)
+	}
+
+	h.conn = conn
+	h.backoff = 0
+	return nil
+}
+
+func (h *SyslogHook) write(msg string) error {
+	switch h.network {
+	case "tcp", "tcp4", "tcp6":
+		// Octet counting as described by RFC 6587
+		msg = strconv.Itoa(len(msg)) + " " + msg
+	case "unix":
+		msg += "\n"
+	}
+
+	h.conn.SetWriteDeadline(time.Now().Add(h.timeout))
+	_, err := h.conn.Write([]byte(msg))
+	return err
+}
+
+// priority returns the PRI value of an entry
+func (h *SyslogHook) priority(logLevel LogLevel) int {
+	return int(h.facility)*8 + syslogSeverity(logLevel)
+}
+
+// syslogSeverity maps a LogLevel to a syslog severity
+func syslogSeverity(logLevel LogLevel) int {
+	switch logLevel {
+	case Trc, Dbg:
+		return 7 // Debug
+	case Nfo:
+		return 6 // Informational
+	case Wrn:
+		return 4 // Warning
+	case Err:
+		return 3 // Error
+	case Pnc:
+		return 2 // Critical
+	}
+	return 1 // Alert
+}
+
+// formatRFC5424 formats an entry as described by RFC 5424:
+//
+//	<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (h *SyslogHook) formatRFC5424(entry *Entry) string {
+	var b strings.Builder
+
+	b.WriteString("<" + strconv.Itoa(h.priority(entry.Level)) + ">1 ")
+	b.WriteString(entry.Time.Format("2006-01-02T15:04:05.000000Z07:00") + " ")
+	b.WriteString(headerField(h.hostname, 255) + " ")
+	b.WriteString(headerField(h.appName, 48) + " ")
+	b.WriteString(headerField(h.procID, 128) + " ")
+	b.WriteString("- ")
+
+	if len(entry.Fields) == 0 {
+		b.WriteString("-")
+	} else {
+		b.WriteString("[" + sdName(h.sdID))
+		for _, k := range sortedKeys(entry.Fields) {
+			b.WriteString(" " + sdName(k) + `="`)
+			sdValue(&b, fmt.Sprint(entry.Fields[k]))
+			b.WriteString(`"`)
+		}
+		b.WriteString("]")
+	}
+
+	if entry.Message != "" {
+		b.WriteString(" " + entry.Message)
+	}
+
+	return b.String()
+}
+
+// formatRFC3164 formats an entry in the BSD syslog format:
+//
+//	<PRI>TIMESTAMP HOSTNAME TAG[PID]: MSG
+func (h *SyslogHook) formatRFC3164(entry *Entry) string {
+	var b strings.Builder
+
+	b.WriteString("<" + strconv.Itoa(h.priority(entry.Level)) + ">")
+	b.WriteString(entry.Time.Format(time.Stamp) + " ")
+	b.WriteString(headerField(h.hostname, 255) + " ")
+	b.WriteString(headerField(h.appName, 32) + "[" + headerField(h.procID, 128) + "]: ")
+	b.WriteString(entry.Message)
+
+	for _, k := range sortedKeys(entry.Fields) {
+		v := fmt.Sprint(entry.Fields[k])
+		if v == "" || strings.ContainsAny(v, " \"=") {
+			v = strconv.Quote(v)
+		}
+		b.WriteString(" " + k + "=" + v)
+	}
+
+	return b.String()
+}
+
+func sortedKeys(fields Fields) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// headerField returns s with anything but printable US-ASCII replaced and
+// truncated to max characters, or the nil value "-" if s is empty
+func headerField(s string, max int) string {
+	if s == "" {
+		return "-"
+	}
+	if len(s) > max {
+		s = s[:max]
+	}
+	return strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// sdName returns s as a valid SD-NAME, which is printable US-ASCII
+// except '=', ' ', ']' and '"', and at most 32 characters
+func sdName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, headerField(s, 32))
+}
+
+// sdValue writes s as a PARAM-VALUE, escaping '"', '\' and ']'
+func sdValue(b *strings.Builder, s string) {
+	for _, r := range s {
+		if r == '"' || r == '\\' || r == ']' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+}
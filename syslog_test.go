@@ -0,0 +1,161 @@
+package wlog
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogFormat(t *testing.T) {
+	h := &SyslogHook{facility: FacilityLocal0, appName: "my app", hostname: "host", procID: "42", sdID: DefaultSyslogSDID}
+	entry := &Entry{
+		Time:    time.Date(2024, 1, 2, 15, 4, 5, 123456000, time.UTC),
+		Level:   Wrn,
+		Message: "disk almost full",
+		Fields:  Fields{"tenant": "acme", "path": `C:\ "data"]`, "bad key=": 1},
+	}
+
+	want := `<132>1 2024-01-02T15:04:05.123456Z host my_app 42 - [fields@32473 bad_key_="1" path="C:\\ \"data\"\]" tenant="acme"] disk almost full`
+	if got := h.formatRFC5424(entry); got != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+
+	entry.Fields = nil
+	want = `<132>1 2024-01-02T15:04:05.123456Z host my_app 42 - - disk almost full`
+	if got := h.formatRFC5424(entry); got != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+
+	entry.Level = Ftl
+	entry.Fields = Fields{"tenant": "acme", "reason": "out of space"}
+	want = `<129>Jan  2 15:04:05 host my_app[42]: disk almost full reason="out of space" tenant=acme`
+	if got := h.formatRFC3164(entry); got != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestSyslogUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	hook, err := NewSyslogHook(&SyslogConfig{Network: "udp", Address: conn.LocalAddr().String(), AppName: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hook.Close()
+
+	logger := New(nil, Nfo, false)
+	logger.AddHook(Nfo, hook)
+	logger.WithScope(Fields{"tenant": "acme"}).Error("failed")
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<11>1 ") || !strings.HasSuffix(msg, ` test `+strconv.Itoa(os.Getpid())+` - [fields@32473 tenant="acme"] failed`) {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
+
+func TestSyslogUnixgram(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "log")
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Skip("unixgram sockets are not supported:", err)
+	}
+	defer conn.Close()
+
+	hook, err := NewSyslogHook(&SyslogConfig{Network: "unixgram", Address: path, Format: RFC3164, AppName: "test", ProcID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hook.Close()
+
+	if err := hook.Fire(&Entry{Time: time.Now(), Level: Dbg, Message: "debug"}); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := string(buf[:n]); !strings.HasPrefix(msg, "<15>") || !strings.HasSuffix(msg, " test[1]: debug") {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
+
+func TestSyslogTCPReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	messages := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			// Read one octet counted message per connection
+			r := bufio.NewReader(conn)
+			length, err := r.ReadString(' ')
+			if err == nil {
+				n, _ := strconv.Atoi(strings.TrimSpace(length))
+				msg := make([]byte, n)
+				if _, err := io.ReadFull(r, msg); err == nil {
+					messages <- string(msg)
+				}
+			}
+			conn.Close()
+		}
+	}()
+
+	hook, err := NewSyslogHook(&SyslogConfig{Network: "tcp", Address: ln.Addr().String(), MinBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hook.Close()
+
+	for _, want := range []string{"first", "second"} {
+		deadline := time.After(5 * time.Second)
+	wait:
+		for {
+			hook.Fire(&Entry{Time: time.Now(), Level: Nfo, Message: want})
+
+			select {
+			case msg := <-messages:
+				if strings.HasSuffix(msg, " - - "+want) {
+					break wait
+				}
+			case <-deadline:
+				t.Fatalf("%s message not received", want)
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+}
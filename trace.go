@@ -0,0 +1,213 @@
+package wlog
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// TraceParentHeader is the HTTP header carrying a W3C trace context
+const TraceParentHeader = "traceparent"
+
+// The names of the fields added for a TraceContext. They are
+// subject to the FieldMapping of the logger
+const (
+	TraceIDField    = "trace_id"
+	SpanIDField     = "span_id"
+	TraceFlagsField = "trace_flags"
+)
+
+// ErrInvalidTraceParent is returned when parsing a malformed traceparent value
+var ErrInvalidTraceParent = errors.New("invalid traceparent")
+
+// TraceContext holds the correlation identifiers of a
+// W3C trace context (https://www.w3.org/TR/trace-context/)
+type TraceContext struct {
+	TraceID string
+	SpanID  string
+	Flags   byte
+}
+
+// ParseTraceParent parses the value of a W3C traceparent header,
+// e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceParent(value string) (TraceContext, error) {
+	// version "-" trace-id "-" parent-id "-" trace-flags
+	if len(value) < 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+
+	version := value[0:2]
+	if !isLowerHex(version) || version == "ff" {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+
+	// Version 00 has a fixed length. Later versions may append
+	// more data which must be preceded by a dash
+	if (version == "00" && len(value) != 55) || (len(value) > 55 && value[55] != '-') {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+
+	tc := TraceContext{
+		TraceID: value[3:35],
+		SpanID:  value[36:52],
+	}
+
+	flags := value[53:55]
+	if !isLowerHex(tc.TraceID) || !isLowerHex(tc.SpanID) || !isLowerHex(flags) {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+	tc.Flags = unhex(flags[0])<<4 | unhex(flags[1])
+
+	if !tc.IsValid() {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+
+	return tc, nil
+}
+
+// IsValid reports whether the trace and span ids are set and not all zeros
+func (tc TraceContext) IsValid() bool {
+	return len(tc.TraceID) == 32 && len(tc.SpanID) == 16 && !isZeros(tc.TraceID) && !isZeros(tc.SpanID)
+}
+
+// Sampled reports whether the sampled flag is set
+func (tc TraceContext) Sampled() bool {
+	return tc.Flags&0x01 == 0x01
+}
+
+// String returns tc formatted as a version 00 traceparent value
+func (tc TraceContext) String() string {
+	return "00-" + tc.TraceID + "-" + tc.SpanID + "-" + tc.flags()
+}
+
+func (tc TraceContext) flags() string {
+	return string([]byte{hex[tc.Flags>>4], hex[tc.Flags&0x0f]})
+}
+
+// appendFields appends the trace fields to typed using the names
+// mapped by fieldMapping
+func (tc TraceContext) appendFields(typed []Field, fieldMapping FieldMapping) []Field {
+	return append(typed,
+		String(mapKey(TraceIDField, fieldMapping), tc.TraceID),
+		String(mapKey(SpanIDField, fieldMapping), tc.SpanID),
+		String(mapKey(TraceFlagsField, fieldMapping), tc.flags()),
+	)
+}
+
+// traceContextKey is the context key used to attach a TraceContext or
+// a traceparent value to a context.Context
+type traceContextKey struct{}
+
+var (
+	traceParentKeysMutex sync.RWMutex
+	traceParentKeys      []interface{}
+)
+
+// RegisterTraceParentKey registers a context key holding a traceparent value,
+// either as a string or a TraceContext. TraceContextFromContext will look for
+// a trace context using the key
+func RegisterTraceParentKey(key interface{}) {
+	traceParentKeysMutex.Lock()
+	defer traceParentKeysMutex.Unlock()
+
+	for _, k := range traceParentKeys {
+		if k == key {
+			return
+		}
+	}
+
+	traceParentKeys = append(traceParentKeys, key)
+}
+
+// ContextWithTrace returns a copy of ctx carrying tc
+func ContextWithTrace(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// TraceContextFromContext returns the trace context carried by ctx, either
+// attached with ContextWithTrace or stored under a key registered with
+// RegisterTraceParentKey
+func TraceContextFromContext(ctx context.Context) (TraceContext, bool) {
+	if ctx == nil {
+		return TraceContext{}, false
+	}
+
+	if tc, ok := traceContextValue(ctx.Value(traceContextKey{})); ok {
+		return tc, true
+	}
+
+	traceParentKeysMutex.RLock()
+	defer traceParentKeysMutex.RUnlock()
+
+	for _, key := range traceParentKeys {
+		if tc, ok := traceContextValue(ctx.Value(key)); ok {
+			return tc, true
+		}
+	}
+
+	return TraceContext{}, false
+}
+
+// TraceContextFromRequest returns the trace context of the traceparent
+// header of r. The context of r is used if the header isn't valid
+func TraceContextFromRequest(r *http.Request) (TraceContext, bool) {
+	if tc, err := ParseTraceParent(r.Header.Get(TraceParentHeader)); err == nil {
+		return tc, true
+	}
+	return TraceContextFromContext(r.Context())
+}
+
+// WithTrace returns a new instance of Logger based on logger with the
+// trace_id, span_id and trace_flags fields of tc. The logger is returned
+// as is if tc is not valid
+func WithTrace(logger Logger, tc TraceContext) Logger {
+	if !tc.IsValid() {
+		return logger
+	}
+	return logger.With(tc.appendFields(make([]Field, 0, 3), logger.GetFieldMapping())...)
+}
+
+func traceContextValue(v interface{}) (TraceContext, bool) {
+	switch v := v.(type) {
+	case TraceContext:
+		return v, v.IsValid()
+	case string:
+		tc, err := ParseTraceParent(v)
+		return tc, err == nil
+	}
+	return TraceContext{}, false
+}
+
+// mapKey returns the name key is mapped to by fieldMapping, or key itself
+func mapKey(key string, fieldMapping FieldMapping) string {
+	if mapped, ok := fieldMapping[key]; ok {
+		return mapped
+	}
+	return key
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZeros(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '0' {
+			return false
+		}
+	}
+	return true
+}
+
+func unhex(c byte) byte {
+	if c >= 'a' {
+		return c - 'a' + 10
+	}
+	return c - '0'
+}
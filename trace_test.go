@@ -0,0 +1,90 @@
+package wlog
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{testTraceParent, true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		tc, err := ParseTraceParent(tt.value)
+		if (err == nil) != tt.valid {
+			t.Errorf("ParseTraceParent(%q) error = %v, want valid %t", tt.value, err, tt.valid)
+		}
+		if tt.valid && (tc.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || tc.SpanID != "00f067aa0ba902b7") {
+			t.Errorf("ParseTraceParent(%q) = %+v", tt.value, tc)
+		}
+	}
+
+	tc, _ := ParseTraceParent(testTraceParent)
+	if !tc.Sampled() || tc.String() != testTraceParent {
+		t.Errorf("TraceContext = %s, sampled %t", tc, tc.Sampled())
+	}
+}
+
+func TestWithTrace(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+	logger.SetFormatter(JSONFormatter{})
+	logger.SetFieldMapping(FieldMapping{TraceIDField: "traceId"})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(TraceParentHeader, testTraceParent)
+
+	tc, ok := TraceContextFromRequest(r)
+	if !ok {
+		t.Fatalf("expected a trace context")
+	}
+
+	WithTrace(logger, tc).Info("traced")
+
+	got := w.String()
+	for _, want := range []string{`"traceId":"4bf92f3577b34da6a3ce929d0e0e4736"`, `"span_id":"00f067aa0ba902b7"`, `"trace_flags":"01"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output %q should contain %q", got, want)
+		}
+	}
+
+	if WithTrace(logger, TraceContext{}) != logger {
+		t.Errorf("WithTrace() should return the logger for an invalid trace context")
+	}
+}
+
+func TestTraceContextFromContext(t *testing.T) {
+	RegisterTraceParentKey(testContextKey("traceparent"))
+
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+
+	ctx := context.WithValue(context.Background(), testContextKey("traceparent"), testTraceParent)
+
+	logger.InfoCtx(ctx, "traced")
+
+	if got := w.String(); !strings.Contains(got, "trace_id: 4bf92f3577b34da6a3ce929d0e0e4736") {
+		t.Errorf("output %q should contain the trace id", got)
+	}
+
+	tc, _ := ParseTraceParent(testTraceParent)
+	if got, ok := TraceContextFromContext(ContextWithTrace(context.Background(), tc)); !ok || got != tc {
+		t.Errorf("TraceContextFromContext() = %+v, %t", got, ok)
+	}
+}
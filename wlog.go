@@ -2,11 +2,13 @@ package wlog
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -18,22 +20,45 @@ type Config struct {
 	StdOut          bool
 	Formatter       Formatter
 	Writer          io.Writer
+	Sampling        *SamplingConfig
+	DedupWindow     time.Duration
 }
 
 // LogLevel controls how verbose the output will be
 type LogLevel int
 
-// The Log levels available
+// The Log levels available. The values are part of the API, e.g. when
+// stored in a configuration. Pnc ranks between Err and Ftl but was added
+// later and has the highest value
 const (
-	Dbg LogLevel = iota
-	Nfo
-	Wrn
-	Err
-	Ftl
+	Trc LogLevel = -1
+	Dbg LogLevel = 0
+	Nfo LogLevel = 1
+	Wrn LogLevel = 2
+	Err LogLevel = 3
+	Ftl LogLevel = 4
+	Pnc LogLevel = 5
 )
 
+// levels holds the log levels in order of severity
+var levels = []LogLevel{Trc, Dbg, Nfo, Wrn, Err, Pnc, Ftl}
+
+// severity returns the rank of the log level, which is used rather than
+// its value when comparing levels
+func (l LogLevel) severity() int {
+	switch l {
+	case Pnc:
+		return int(Ftl)
+	case Ftl:
+		return int(Ftl) + 1
+	}
+	return int(l)
+}
+
 func (l LogLevel) String() string {
 	switch l {
+	case Trc:
+		return "Trace"
 	case Dbg:
 		return "Debug"
 	case Nfo:
@@ -42,6 +67,8 @@ func (l LogLevel) String() string {
 		return "Warning"
 	case Err:
 		return "Error"
+	case Pnc:
+		return "Panic"
 	case Ftl:
 		return "Fatal"
 	}
@@ -70,6 +97,9 @@ func newLogger(writer io.Writer, logLevel LogLevel, stdOut bool) *logger {
 		formatter:    TextFormatter{},
 		fields:       Fields{},
 		fieldMapping: FieldMapping{"level": "@l", "timestamp": "@t", "message": "@m"},
+		exitFunc:     os.Exit,
+		exitCode:     1,
+		counters:     &counters{},
 	}
 }
 
@@ -84,6 +114,8 @@ func New(writer io.Writer, logLevel LogLevel, stdOut bool) MutableLogger {
 
 // Logger is the interface that wlog loggers implements
 type Logger interface {
+	Tracef(format string, v ...interface{})
+	Trace(v ...interface{})
 	Debugf(format string, v ...interface{})
 	Debug(v ...interface{})
 	Infof(format string, v ...interface{})
@@ -92,6 +124,8 @@ type Logger interface {
 	Warning(v ...interface{})
 	Errorf(format string, v ...interface{})
 	Error(v ...interface{})
+	Panicf(format string, v ...interface{})
+	Panic(v ...interface{})
 	Fatalf(format string, v ...interface{})
 	Fatal(v ...interface{})
 	GetFields() Fields
@@ -99,6 +133,15 @@ type Logger interface {
 	GetLogLevel() LogLevel
 	GetFormatter() Formatter
 	WithScope(fields Fields) Logger
+	With(fields ...Field) Logger
+	WithContext(ctx context.Context) Logger
+	DebugCtx(ctx context.Context, v ...interface{})
+	InfoCtx(ctx context.Context, v ...interface{})
+	WarningCtx(ctx context.Context, v ...interface{})
+	ErrorCtx(ctx context.Context, v ...interface{})
+	Writer(logLevel LogLevel) io.WriteCloser
+	InstallHook(logLevel LogLevel, hook HookFunc) func()
+	AddHook(logLevel LogLevel, hook Hook) func()
 }
 
 // MutableLogger extends the Logger interface by providing
@@ -111,7 +154,14 @@ type MutableLogger interface {
 	SetLogLevel(logLevel LogLevel)
 	Configure(cfg *Config)
 	SetFieldMapping(fieldMapping FieldMapping)
-	InstallHook(logLevel LogLevel, hook HookFunc)
+	ClearHooks()
+	SetReportCaller(enable bool)
+	SetExitFunc(exitFunc func(code int))
+	SetExitCode(code int)
+	RegisterExitHandler(handler func())
+	Stats() Stats
+	SetSampling(cfg *SamplingConfig)
+	SetDedupWindow(window time.Duration)
 }
 
 // FieldMapping is used to map field names when using
@@ -129,10 +179,27 @@ type logger struct {
 	logLevel     LogLevel
 	stdOut       bool
 	mutex        sync.Mutex
-	hooks        map[LogLevel][]HookFunc
+	hooks        []installedHook
+	hookID       uint64
+	reportCaller bool
 	fields       Fields
 	formatter    Formatter
 	fieldMapping FieldMapping
+	exitFunc     func(code int)
+	exitCode     int
+	exitHandlers []func()
+	counters     *counters
+	sampler      atomic.Value
+	deduper      atomic.Value
+
+	// handler, if set, is passed the entries instead of the formatter
+	// and outputs. It's called without holding the lock
+	handler entryHandler
+}
+
+// entryHandler passes entries on to another logging backend
+type entryHandler interface {
+	handleEntry(logLevel LogLevel, msg string, timestamp time.Time, fields Fields, typed []Field) error
 }
 
 var bufferPool = sync.Pool{New: func() interface{} {
@@ -175,6 +242,8 @@ func (l *logger) SetFieldMapping(fieldMapping FieldMapping) {
 func (l *logger) Configure(cfg *Config) {
 	l.SetLogLevel(cfg.LogLevel)
 	l.SetStdOut(cfg.StdOut)
+	l.SetSampling(cfg.Sampling)
+	l.SetDedupWindow(cfg.DedupWindow)
 
 	if cfg.Formatter != nil {
 		l.SetFormatter(cfg.Formatter)
@@ -221,7 +290,21 @@ func (l *logger) WithScope(fields Fields) Logger {
 		scopeFields[k] = v
 	}
 
-	return &scopedLogger{l, scopeFields}
+	return &scopedLogger{logger: l, fields: scopeFields}
+}
+
+// With returns a new instance of Logger with the typed fields added
+// to it's scope. The fields of this instance are inherited
+func (l *logger) With(fields ...Field) Logger {
+	scope := l.WithScope(nil).(*scopedLogger)
+	scope.typed = append([]Field(nil), fields...)
+	return scope
+}
+
+// WithContext returns a new instance of Logger with the values of
+// any registered context keys and the trace context of ctx added as fields
+func (l *logger) WithContext(ctx context.Context) Logger {
+	return l.With(contextFields(ctx, nil, l.GetFieldMapping())...)
 }
 
 // SetGlobalFields set fields in a log instance. These fields will be appended to any
@@ -245,6 +328,28 @@ func (l *logger) SetFields(f Fields) {
 	l.fields = fields
 }
 
+// Tracef formats and logs a trace message
+func (l *logger) Tracef(format string, v ...interface{}) {
+	// Trace is the most verbose level. Catch log-level
+	// early to save unnecessary parsing
+	if Trc < l.logLevel {
+		return
+	}
+
+	l.write(Trc, fmt.Sprintf(format, v...))
+}
+
+// Trace logs a trace message
+func (l *logger) Trace(v ...interface{}) {
+	// Trace is the most verbose level. Catch log-level
+	// early to save unnecessary parsing
+	if Trc < l.logLevel {
+		return
+	}
+
+	l.write(Trc, fmt.Sprint(v...))
+}
+
 // Debugf formats and logs a debug message
 func (l *logger) Debugf(format string, v ...interface{}) {
 	// Debug is very verbose. Catch log-level early
@@ -297,57 +402,322 @@ func (l *logger) Error(v ...interface{}) {
 	l.write(Err, fmt.Sprint(v...))
 }
 
+// Panicf formats and logs a message at the Panic level and then panics with it
+func (l *logger) Panicf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	l.write(Pnc, msg)
+	panic(msg)
+}
+
+// Panic logs a message at the Panic level and then panics with it
+func (l *logger) Panic(v ...interface{}) {
+	msg := fmt.Sprint(v...)
+	l.write(Pnc, msg)
+	panic(msg)
+}
+
 // Fatalf formats and logs an unrecoverable error message
 func (l *logger) Fatalf(format string, v ...interface{}) {
 	l.write(Ftl, fmt.Sprintf(format, v...))
-	os.Exit(1)
+	l.exit(0)
 }
 
 // Fatal logs an unrecoverable error message
 func (l *logger) Fatal(v ...interface{}) {
 	l.write(Ftl, fmt.Sprint(v...))
-	os.Exit(1)
+	l.exit(0)
+}
+
+// SetExitFunc sets the function called by Fatal and Fatalf to terminate
+// the process. A nil function restores the default, os.Exit
+func (l *logger) SetExitFunc(exitFunc func(code int)) {
+	if exitFunc == nil {
+		exitFunc = os.Exit
+	}
+
+	l.lock()
+	defer l.unlock()
+	l.exitFunc = exitFunc
+}
+
+// SetExitCode sets the exit code used by Fatal and Fatalf. Default is 1
+func (l *logger) SetExitCode(code int) {
+	l.lock()
+	defer l.unlock()
+	l.exitCode = code
 }
 
-// InstallHook installs a hook that will be called when a log event occurs
-func (l *logger) InstallHook(logLevel LogLevel, hook HookFunc) {
+// RegisterExitHandler registers a handler that is called by Fatal and Fatalf
+// before terminating the process, after the outputs have been flushed.
+// Handlers are called in the order they were registered
+func (l *logger) RegisterExitHandler(handler func()) {
 	l.lock()
 	defer l.unlock()
+	l.exitHandlers = append(l.exitHandlers, handler)
+}
+
+// exit flushes the outputs, runs the exit handlers and terminates the
+// process using the exit function. A code of 0 means the exit code of
+// the logger
+func (l *logger) exit(code int) {
+	l.flush()
+
+	l.lock()
+	handlers := l.exitHandlers
+	exitFunc := l.exitFunc
+	if code == 0 {
+		code = l.exitCode
+	}
+	l.unlock()
+
+	for _, handler := range handlers {
+		runExitHandler(handler)
+	}
+
+	exitFunc(code)
+}
+
+// runExitHandler calls handler, making sure a panicking handler
+// doesn't prevent the remaining ones from running
+func runExitHandler(handler func()) {
+	defer func() {
+		if v := recover(); v != nil {
+			fmt.Fprintf(os.Stderr, "exit handler panicked: %v", v)
+		}
+	}()
+	handler()
+}
+
+// DebugCtx logs a debug message with the registered context keys of ctx as fields
+func (l *logger) DebugCtx(ctx context.Context, v ...interface{}) {
+	// Debug is very verbose. Catch log-level early
+	// to save unnecessary parsing
+	if Dbg < l.logLevel {
+		return
+	}
+
+	l.writeCtx(ctx, Dbg, fmt.Sprint(v...))
+}
+
+// InfoCtx logs an informal message with the registered context keys of ctx as fields
+func (l *logger) InfoCtx(ctx context.Context, v ...interface{}) {
+	l.writeCtx(ctx, Nfo, fmt.Sprint(v...))
+}
+
+// WarningCtx logs a warning message with the registered context keys of ctx as fields
+func (l *logger) WarningCtx(ctx context.Context, v ...interface{}) {
+	l.writeCtx(ctx, Wrn, fmt.Sprint(v...))
+}
+
+// ErrorCtx logs an error message with the registered context keys of ctx as fields
+func (l *logger) ErrorCtx(ctx context.Context, v ...interface{}) {
+	l.writeCtx(ctx, Err, fmt.Sprint(v...))
+}
+
+// Writer returns an io.WriteCloser logging each line written to it as an
+// entry at logLevel. Close logs any remaining unterminated line
+func (l *logger) Writer(logLevel LogLevel) io.WriteCloser {
+	return newLineWriter(l, logLevel)
+}
+
+// InstallHook installs a hook that will be called when a log event occurs.
+// The returned function removes the hook
+func (l *logger) InstallHook(logLevel LogLevel, hook HookFunc) func() {
+	return l.installHook(installedHook{logLevel: logLevel, exact: true, hook: hookFunc(hook)})
+}
+
+// AddHook installs a hook that will be called for log entries at
+// logLevel and above. The returned function removes the hook
+func (l *logger) AddHook(logLevel LogLevel, hook Hook) func() {
+	return l.installHook(installedHook{logLevel: logLevel, hook: hook})
+}
+
+// ClearHooks removes all hooks installed on the logger
+func (l *logger) ClearHooks() {
+	l.lock()
+	defer l.unlock()
+	l.hooks = nil
+}
+
+func (l *logger) installHook(h installedHook) func() {
+	l.lock()
+	defer l.unlock()
+
+	l.hookID++
+	h.id = l.hookID
+	l.hooks = append(l.hooks, h)
+
+	return func() {
+		l.lock()
+		defer l.unlock()
+		l.hooks = removeHook(l.hooks, h.id)
+	}
+}
+
+// SetSampling enables sampling of log entries as configured by cfg,
+// or disables it if cfg is nil
+func (l *logger) SetSampling(cfg *SamplingConfig) {
+	var s *sampler
+	if cfg != nil {
+		s = newSampler(cfg)
+		s.expired = func(summaries []sampleSummary) {
+			l.writeSummaries(summaries, l.GetFieldMapping(), time.Now())
+		}
+	}
+
+	previous, _ := l.sampler.Load().(*sampler)
+	l.sampler.Store(s)
+
+	// Report the entries suppressed by the previous sampler
+	if previous != nil {
+		l.writeSummaries(previous.flush(), l.GetFieldMapping(), time.Now())
+	}
+}
 
-	if l.hooks == nil {
-		l.hooks = make(map[LogLevel][]HookFunc)
+// SetDedupWindow enables collapsing of consecutive duplicate entries, with
+// the same level, message and fields, written within window of the first
+// one. The duplicates are replaced by an entry reporting the number of
+// repeats in the repeat_count field. Fields are compared without being
+// formatted, so entries with lazy values are never collapsed. A window
+// of 0 disables it
+func (l *logger) SetDedupWindow(window time.Duration) {
+	var d *deduper
+	if window > 0 {
+		d = newDeduper(window)
+		d.expired = func(entry *repeatedEntry, repeats int) {
+			l.writeRepeated(entry, repeats, time.Now())
+		}
 	}
 
-	l.hooks[logLevel] = append(l.hooks[logLevel], hook)
+	previous, _ := l.deduper.Load().(*deduper)
+	l.deduper.Store(d)
+
+	// Report the repeats collapsed by the previous window
+	if previous != nil {
+		if entry, repeats := previous.flush(); entry != nil {
+			l.writeRepeated(entry, repeats, time.Now())
+		}
+	}
+}
+
+// SetReportCaller sets or clears reporting of the caller in the entries
+// passed to hooks. Finding the caller has a cost for every entry
+func (l *logger) SetReportCaller(enable bool) {
+	l.lock()
+	defer l.unlock()
+	l.reportCaller = enable
 }
 
 func (l *logger) write(logLevel LogLevel, msg string) {
-	l.writeWithFields(logLevel, msg, l.GetFields(), l.GetFieldMapping())
+	l.writeWithFields(logLevel, msg, l.GetFields(), nil, l.GetFieldMapping(), nil)
+}
+
+func (l *logger) writeCtx(ctx context.Context, logLevel LogLevel, msg string) {
+	if logLevel.severity() < l.logLevel.severity() {
+		return
+	}
+	l.writeWithFields(logLevel, msg, l.GetFields(), contextFields(ctx, nil, l.GetFieldMapping()), l.GetFieldMapping(), nil)
+}
+
+func (l *logger) writeWithFields(logLevel LogLevel, msg string, fields Fields, typed []Field, fieldMapping FieldMapping, scope *scopedLogger) {
+	l.writeAt(logLevel, msg, time.Now(), fields, typed, fieldMapping, scope)
 }
 
-func (l *logger) writeWithFields(logLevel LogLevel, msg string, fields Fields, fieldMapping FieldMapping) {
+// writeAt writes an entry with the timestamp now
+func (l *logger) writeAt(logLevel LogLevel, msg string, now time.Time, fields Fields, typed []Field, fieldMapping FieldMapping, scope *scopedLogger) {
+	// Scopes created with FingersCrossed may hold the entry back
+	if scope != nil && scope.buffer != nil && scope.buffer.write(l, logLevel, msg, now, fields, typed, fieldMapping, scope) {
+		return
+	}
 
 	// Ignore write if severity level is less than configured level
-	if logLevel < l.logLevel {
+	if logLevel.severity() < l.logLevel.severity() {
 		return
 	}
 
-	now := time.Now()
+	// Collapse duplicates before the entry is formatted
+	if deduper, _ := l.deduper.Load().(*deduper); deduper != nil {
+		entry := &repeatedEntry{
+			logLevel:     logLevel,
+			msg:          msg,
+			fields:       fields,
+			typed:        typed,
+			fieldMapping: fieldMapping,
+			scope:        scope,
+		}
+		write, previous, repeats := deduper.check(entry, now)
+		if previous != nil {
+			l.writeRepeated(previous, repeats, now)
+		}
+		if !write {
+			atomic.AddUint64(&l.counters.dropped, 1)
+			return
+		}
+	}
+
+	// Sample the entry before it's formatted
+	if sampler, _ := l.sampler.Load().(*sampler); sampler != nil {
+		keep, summaries := sampler.sample(logLevel, msg, now)
+		l.writeSummaries(summaries, fieldMapping, now)
+		if !keep {
+			atomic.AddUint64(&l.counters.dropped, 1)
+			return
+		}
+	}
+
+	l.emit(logLevel, msg, now, fields, typed, fieldMapping, scope)
+}
+
+// emit writes an entry to the outputs of the logger and calls the hooks
+// of the logger and scope, if any
+func (l *logger) emit(logLevel LogLevel, msg string, now time.Time, fields Fields, typed []Field, fieldMapping FieldMapping, scope *scopedLogger) {
+	l.counters.countEntry(logLevel)
+
+	// Compute lazy values once, for both the formatter and the hooks
+	fields = resolveFields(fields)
+	typed = resolveTyped(typed)
+
+	var hooks []installedHook
+	var reportCaller bool
+	if l.handler != nil {
+		if err := l.handler.handleEntry(logLevel, msg, now, fields, typed); err != nil {
+			atomic.AddUint64(&l.counters.failed, 1)
+			fmt.Fprintf(os.Stderr, "could not pass log entry to handler: %v", err)
+		}
+
+		l.lock()
+		hooks, reportCaller = l.hooks, l.reportCaller
+		l.unlock()
+	} else {
+		entryBuffer := bufferPool.Get().(*bytes.Buffer)
+		defer bufferPool.Put(entryBuffer)
+		entryBuffer.Reset()
+
+		hooks, reportCaller = l.output(entryBuffer, logLevel, msg, now, fields, typed, fieldMapping)
+	}
+	if scope != nil {
+		hooks = scope.appendHooks(hooks)
+	}
 
-	entryBuffer := bufferPool.Get().(*bytes.Buffer)
-	defer bufferPool.Put(entryBuffer)
-	entryBuffer.Reset()
+	// Call any installed hooks. This is done without holding
+	// the lock, allowing hooks to log as well
+	fireHooks(hooks, reportCaller, now, logLevel, msg, fields, typed)
+}
 
+// output formats an entry and writes it to the outputs of the logger. The
+// hooks to call for the entry are returned as they were at the time of writing
+func (l *logger) output(entryBuffer *bytes.Buffer, logLevel LogLevel, msg string, now time.Time, fields Fields, typed []Field, fieldMapping FieldMapping) ([]installedHook, bool) {
 	l.lock()
 	defer l.unlock()
 
-	if err := l.formatter.Format(entryBuffer, logLevel, msg, now, fields, fieldMapping); err != nil {
+	if err := format(l.formatter, entryBuffer, logLevel, msg, now, fields, typed, fieldMapping); err != nil {
 		fmt.Fprintf(os.Stderr, "error formatting the log entry: %v", err)
 	}
 
 	// Write to io.Writer if provided
 	if l.writer != nil {
 		if _, err := l.writer.Write(entryBuffer.Bytes()); err != nil {
+			atomic.AddUint64(&l.counters.failed, 1)
 			fmt.Fprintf(os.Stderr, "could not write log entry to io.Writer: %v", err)
 		}
 	}
@@ -355,19 +725,56 @@ func (l *logger) writeWithFields(logLevel LogLevel, msg string, fields Fields, f
 	// Write to standard output if requested
 	if l.stdOut {
 		output := os.Stdout
-		if logLevel > Wrn {
+		if logLevel.severity() > Wrn.severity() {
 			output = os.Stderr
 		}
 		if _, err := entryBuffer.WriteTo(output); err != nil {
+			atomic.AddUint64(&l.counters.failed, 1)
 			fmt.Fprintf(os.Stderr, "could not write log entry to: %v", output)
 		}
 	}
 
-	// Call any installed hooks
-	if l.hooks != nil {
-		for _, h := range l.hooks[logLevel] {
-			h(now, logLevel, msg)
-		}
+	return l.hooks, l.reportCaller
+}
+
+// entryWriter is implemented by the loggers of this package. It writes an
+// entry at any level through writeAt, without terminating the process for
+// fatal entries. A zero timestamp is replaced with the current time
+type entryWriter interface {
+	writeEntry(logLevel LogLevel, msg string, timestamp time.Time, typed []Field)
+}
+
+// writeEntry implements entryWriter.writeEntry
+func (l *logger) writeEntry(logLevel LogLevel, msg string, timestamp time.Time, typed []Field) {
+	if logLevel.severity() < l.logLevel.severity() {
+		return
+	}
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	l.writeAt(logLevel, msg, timestamp, l.GetFields(), typed, l.GetFieldMapping(), nil)
+}
+
+// logAt logs msg at logLevel. It is used by adapters that must not terminate
+// the process: loggers of this package write fatal entries without exiting
+// and other implementations of Logger get them as errors
+func logAt(logger Logger, logLevel LogLevel, msg string) {
+	if ew, ok := logger.(entryWriter); ok {
+		ew.writeEntry(logLevel, msg, time.Time{}, nil)
+		return
+	}
+
+	switch {
+	case logLevel <= Trc:
+		logger.Trace(msg)
+	case logLevel == Dbg:
+		logger.Debug(msg)
+	case logLevel == Nfo:
+		logger.Info(msg)
+	case logLevel == Wrn:
+		logger.Warning(msg)
+	default:
+		logger.Error(msg)
 	}
 }
 
@@ -421,6 +828,16 @@ func (l *logger) SetStdOut(enable bool) {
 	l.stdOut = enable
 }
 
+// Tracef formats and logs a trace message
+func Tracef(format string, v ...interface{}) {
+	defaultLogger.Tracef(format, v...)
+}
+
+// Trace logs a trace message
+func Trace(v ...interface{}) {
+	defaultLogger.Trace(v...)
+}
+
 // Debugf formats and logs a debug message
 func Debugf(format string, v ...interface{}) {
 	defaultLogger.Debugf(format, v...)
@@ -461,6 +878,16 @@ func Error(v ...interface{}) {
 	defaultLogger.Error(v...)
 }
 
+// Panicf formats and logs a message at the Panic level and then panics with it
+func Panicf(format string, v ...interface{}) {
+	defaultLogger.Panicf(format, v...)
+}
+
+// Panic logs a message at the Panic level and then panics with it
+func Panic(v ...interface{}) {
+	defaultLogger.Panic(v...)
+}
+
 // Fatalf formats and logs an unrecoverable error message
 func Fatalf(format string, v ...interface{}) {
 	defaultLogger.Fatalf(format, v...)
@@ -471,10 +898,69 @@ func Fatal(v ...interface{}) {
 	defaultLogger.Fatal(v...)
 }
 
+// Writer returns an io.WriteCloser logging each line written to it as an
+// entry at logLevel using the default logger
+func Writer(logLevel LogLevel) io.WriteCloser {
+	return defaultLogger.Writer(logLevel)
+}
+
+// SetExitFunc sets the function called by Fatal and Fatalf of the
+// default logger to terminate the process
+func SetExitFunc(exitFunc func(code int)) {
+	defaultLogger.SetExitFunc(exitFunc)
+}
+
+// SetExitCode sets the exit code used by Fatal and Fatalf of the default logger
+func SetExitCode(code int) {
+	defaultLogger.SetExitCode(code)
+}
+
+// RegisterExitHandler registers a handler that is called by Fatal and Fatalf
+// of the default logger before terminating the process
+func RegisterExitHandler(handler func()) {
+	defaultLogger.RegisterExitHandler(handler)
+}
+
 // InstallHook installs a hook to the default logger
-// that will be called when a log event occurs
-func InstallHook(logLevel LogLevel, hook HookFunc) {
-	defaultLogger.InstallHook(logLevel, hook)
+// that will be called when a log event occurs. The
+// returned function removes the hook
+func InstallHook(logLevel LogLevel, hook HookFunc) func() {
+	return defaultLogger.InstallHook(logLevel, hook)
+}
+
+// ClearHooks removes all hooks installed on the default logger
+func ClearHooks() {
+	defaultLogger.ClearHooks()
+}
+
+// AddHook installs a hook to the default logger that will be called
+// for log entries at logLevel and above. The returned function
+// removes the hook
+func AddHook(logLevel LogLevel, hook Hook) func() {
+	return defaultLogger.AddHook(logLevel, hook)
+}
+
+// SetSampling enables sampling of log entries written by the default
+// logger as configured by cfg, or disables it if cfg is nil
+func SetSampling(cfg *SamplingConfig) {
+	defaultLogger.SetSampling(cfg)
+}
+
+// SetDedupWindow enables collapsing of consecutive duplicate entries
+// written by the default logger within window. A window of 0 disables it
+func SetDedupWindow(window time.Duration) {
+	defaultLogger.SetDedupWindow(window)
+}
+
+// GetStats returns the counters of the default logger
+func GetStats() Stats {
+	return defaultLogger.Stats()
+}
+
+// SetReportCaller sets or clears reporting of the caller in the
+// entries passed to hooks of the default logger
+func SetReportCaller(enable bool) {
+	defaultLogger.SetReportCaller(enable)
 }
 
 // SetFieldMapping add custom field mapping for structured log
@@ -530,3 +1016,9 @@ func SetGlobalFields(fields Fields) {
 func WithScope(fields Fields) Logger {
 	return defaultLogger.WithScope(fields)
 }
+
+// With returns a new instance of Logger based on the default logger
+// with the typed fields added to it's scope
+func With(fields ...Field) Logger {
+	return defaultLogger.With(fields...)
+}
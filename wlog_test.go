@@ -3,6 +3,8 @@ package wlog
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
@@ -10,19 +12,23 @@ import (
 func TestHooks(t *testing.T) {
 
 	// Install a hook to catch all NFO (Info) messages
-	InstallHook(Nfo, func(timestamp time.Time, logLevel LogLevel, message string) {
+	removeNfo := InstallHook(Nfo, func(timestamp time.Time, logLevel LogLevel, message string) {
 		if logLevel != Nfo {
 			t.Fatalf("Expected %s but got %s", Nfo, logLevel)
 		}
 	})
 
+	defer removeNfo()
+
 	// Install a hook to catch all WRN (Warning) messages
-	InstallHook(Wrn, func(timestamp time.Time, logLevel LogLevel, message string) {
+	removeWrn := InstallHook(Wrn, func(timestamp time.Time, logLevel LogLevel, message string) {
 		if logLevel != Wrn {
 			t.Fatalf("Expected %s but got %s", Wrn, logLevel)
 		}
 	})
 
+	defer removeWrn()
+
 	Info("This is a NFO log entry")
 	Warning("This is a WRN log entry")
 	Error("This is a ERR log entry. No hooks installed for this level")
@@ -151,3 +157,81 @@ func TestLogLevel(t *testing.T) {
 		t.Fatalf("unexpected log output")
 	}
 }
+
+func TestPanic(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+
+	for _, l := range []Logger{logger, logger.WithScope(Fields{"scope": "child"})} {
+		w.Reset()
+		func() {
+			defer func() {
+				if v := recover(); v != "invalid state 42" {
+					t.Errorf("expected a panic with the message, got %v", v)
+				}
+			}()
+			l.Panicf("invalid state %d", 42)
+		}()
+
+		if got := w.String(); !strings.Contains(got, " PNC invalid state 42") {
+			t.Errorf("unexpected log output %q", got)
+		}
+	}
+
+	if Pnc.severity() <= Err.severity() || Pnc.severity() >= Ftl.severity() || Pnc.String() != "Panic" {
+		t.Errorf("unexpected Pnc level %d %s", Pnc, Pnc)
+	}
+}
+
+func TestFatalExit(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+
+	var calls []string
+	logger.RegisterExitHandler(func() { calls = append(calls, "first") })
+	logger.RegisterExitHandler(func() { panic("handler failed") })
+	logger.RegisterExitHandler(func() { calls = append(calls, "last") })
+	logger.SetExitCode(3)
+	logger.SetExitFunc(func(code int) { calls = append(calls, fmt.Sprintf("exit %d", code)) })
+
+	logger.WithScope(Fields{"scope": "child"}).Fatal("unrecoverable")
+
+	if got := strings.Join(calls, ", "); got != "first, last, exit 3" {
+		t.Errorf("unexpected exit sequence %q", got)
+	}
+	if !strings.Contains(w.String(), " FTL unrecoverable") {
+		t.Errorf("unexpected log output %q", w.String())
+	}
+
+	calls = nil
+	func() {
+		defer Recover(logger, &RecoverOptions{Action: RecoverExit, ExitCode: 2})
+		panic("boom")
+	}()
+	if got := strings.Join(calls, ", "); got != "first, last, exit 2" {
+		t.Errorf("unexpected exit sequence %q", got)
+	}
+}
+
+func TestLogLevelValues(t *testing.T) {
+	// The numeric values are part of the API
+	for logLevel, want := range map[LogLevel]int{Trc: -1, Dbg: 0, Nfo: 1, Wrn: 2, Err: 3, Ftl: 4, Pnc: 5} {
+		if int(logLevel) != want {
+			t.Errorf("%v = %d, want %d", logLevel, int(logLevel), want)
+		}
+	}
+
+	// Pnc ranks below Ftl despite its higher value
+	w := &bytes.Buffer{}
+	logger := New(w, Ftl, false).(*logger)
+	logger.writeEntry(Pnc, "filtered", time.Time{}, nil)
+	if w.Len() > 0 {
+		t.Errorf("unexpected log output %q", w.String())
+	}
+
+	logger.SetLogLevel(Pnc)
+	logger.writeEntry(Ftl, "written", time.Time{}, nil)
+	if !strings.Contains(w.String(), "FTL written") {
+		t.Errorf("unexpected log output %q", w.String())
+	}
+}
@@ -0,0 +1,101 @@
+// Package wloggrpc provides a gRPC logger backed by a wlog Logger. Logger
+// implements the grpclog.LoggerV2 interface without depending on gRPC:
+//
+//	grpclog.SetLoggerV2(wloggrpc.NewLogger(wlog.DefaultLogger()))
+package wloggrpc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vargspjut/wlog"
+)
+
+// Logger implements grpclog.LoggerV2 on top of a wlog Logger. Every
+// entry has the field system=grpc
+type Logger struct {
+	logger wlog.Logger
+}
+
+// NewLogger returns a Logger writing entries to logger
+func NewLogger(logger wlog.Logger) *Logger {
+	return &Logger{logger: logger.WithScope(wlog.Fields{"system": "grpc"})}
+}
+
+// Info logs to INFO log. Arguments are handled in the manner of fmt.Print
+func (l *Logger) Info(args ...interface{}) {
+	l.logger.Info(args...)
+}
+
+// Infoln logs to INFO log. Arguments are handled in the manner of fmt.Println
+func (l *Logger) Infoln(args ...interface{}) {
+	l.logger.Info(sprintln(args))
+}
+
+// Infof logs to INFO log. Arguments are handled in the manner of fmt.Printf
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.logger.Infof(format, args...)
+}
+
+// Warning logs to WARNING log. Arguments are handled in the manner of fmt.Print
+func (l *Logger) Warning(args ...interface{}) {
+	l.logger.Warning(args...)
+}
+
+// Warningln logs to WARNING log. Arguments are handled in the manner of fmt.Println
+func (l *Logger) Warningln(args ...interface{}) {
+	l.logger.Warning(sprintln(args))
+}
+
+// Warningf logs to WARNING log. Arguments are handled in the manner of fmt.Printf
+func (l *Logger) Warningf(format string, args ...interface{}) {
+	l.logger.Warningf(format, args...)
+}
+
+// Error logs to ERROR log. Arguments are handled in the manner of fmt.Print
+func (l *Logger) Error(args ...interface{}) {
+	l.logger.Error(args...)
+}
+
+// Errorln logs to ERROR log. Arguments are handled in the manner of fmt.Println
+func (l *Logger) Errorln(args ...interface{}) {
+	l.logger.Error(sprintln(args))
+}
+
+// Errorf logs to ERROR log. Arguments are handled in the manner of fmt.Printf
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.logger.Errorf(format, args...)
+}
+
+// Fatal logs to FATAL log and exits. Arguments are handled in the manner of fmt.Print
+func (l *Logger) Fatal(args ...interface{}) {
+	l.logger.Fatal(args...)
+}
+
+// Fatalln logs to FATAL log and exits. Arguments are handled in the manner of fmt.Println
+func (l *Logger) Fatalln(args ...interface{}) {
+	l.logger.Fatal(sprintln(args))
+}
+
+// Fatalf logs to FATAL log and exits. Arguments are handled in the manner of fmt.Printf
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	l.logger.Fatalf(format, args...)
+}
+
+// V reports whether verbosity level v is enabled. Verbosity 0 is enabled
+// when the log level of the logger is Info or lower, 1 for Debug and
+// higher verbosity levels for Trace
+func (l *Logger) V(v int) bool {
+	level := wlog.Nfo
+	switch {
+	case v == 1:
+		level = wlog.Dbg
+	case v > 1:
+		level = wlog.Trc
+	}
+	return level >= l.logger.GetLogLevel()
+}
+
+func sprintln(args []interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+}
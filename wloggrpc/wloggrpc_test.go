@@ -0,0 +1,58 @@
+package wloggrpc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/vargspjut/wlog"
+)
+
+// loggerV2 mirrors grpclog.LoggerV2
+type loggerV2 interface {
+	Info(args ...interface{})
+	Infoln(args ...interface{})
+	Infof(format string, args ...interface{})
+	Warning(args ...interface{})
+	Warningln(args ...interface{})
+	Warningf(format string, args ...interface{})
+	Error(args ...interface{})
+	Errorln(args ...interface{})
+	Errorf(format string, args ...interface{})
+	Fatal(args ...interface{})
+	Fatalln(args ...interface{})
+	Fatalf(format string, args ...interface{})
+	V(l int) bool
+}
+
+var _ loggerV2 = (*Logger)(nil)
+
+func TestLogger(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := wlog.New(w, wlog.Nfo, false)
+
+	l := NewLogger(logger)
+	l.Infoln("transport:", "closing")
+	l.Warningf("retrying %d", 3)
+	l.Error("connection failed")
+
+	lines := strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n")
+	want := []string{" NFO transport: closing [system: grpc]", " WRN retrying 3 [system: grpc]", " ERR connection failed [system: grpc]"}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d entries, got %q", len(want), lines)
+	}
+	for i := range want {
+		if !strings.HasSuffix(lines[i], want[i]) {
+			t.Errorf("entry %q should end with %q", lines[i], want[i])
+		}
+	}
+
+	if !l.V(0) || l.V(1) || l.V(2) {
+		t.Errorf("only verbosity 0 should be enabled at Info")
+	}
+
+	logger.SetLogLevel(wlog.Trc)
+	if !l.V(2) {
+		t.Errorf("verbosity 2 should be enabled at Trace")
+	}
+}
@@ -0,0 +1,8 @@
+module github.com/vargspjut/wlog/wlogr
+
+go 1.18
+
+require (
+	github.com/go-logr/logr v1.4.4
+	github.com/vargspjut/wlog v1.1.0
+)
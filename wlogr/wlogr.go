@@ -0,0 +1,110 @@
+// Package wlogr provides a logr.LogSink backed by a wlog Logger, making
+// wlog usable with libraries taking a logr.Logger such as the Kubernetes
+// client libraries and controller-runtime
+package wlogr
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/vargspjut/wlog"
+)
+
+// NameField is the field holding the name of a logger created with WithName
+const NameField = "logger"
+
+// logSink implements logr.LogSink on top of a wlog Logger
+type logSink struct {
+	logger wlog.Logger
+	name   string
+}
+
+// New returns a logr.Logger writing entries to logger
+func New(logger wlog.Logger) logr.Logger {
+	return logr.New(NewLogSink(logger))
+}
+
+// NewLogSink returns a logr.LogSink writing entries to logger. V-level 0 is
+// logged as Info, V-level 1 as Debug and higher V-levels as Trace. Errors are
+// logged as Error with the error in the "error" field
+func NewLogSink(logger wlog.Logger) logr.LogSink {
+	return &logSink{logger: logger}
+}
+
+// Init implements logr.LogSink.Init
+func (s *logSink) Init(logr.RuntimeInfo) {}
+
+// Enabled implements logr.LogSink.Enabled
+func (s *logSink) Enabled(level int) bool {
+	return toLogLevel(level) >= s.logger.GetLogLevel()
+}
+
+// Info implements logr.LogSink.Info
+func (s *logSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	logger := s.logger.WithScope(toFields(keysAndValues))
+
+	switch toLogLevel(level) {
+	case wlog.Trc:
+		logger.Trace(msg)
+	case wlog.Dbg:
+		logger.Debug(msg)
+	default:
+		logger.Info(msg)
+	}
+}
+
+// Error implements logr.LogSink.Error
+func (s *logSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.logger.WithScope(toFields(keysAndValues)).With(wlog.ErrorField(err)).Error(msg)
+}
+
+// WithValues implements logr.LogSink.WithValues
+func (s *logSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &logSink{logger: s.logger.WithScope(toFields(keysAndValues)), name: s.name}
+}
+
+// WithName implements logr.LogSink.WithName. Names are joined with
+// a slash and written in the "logger" field
+func (s *logSink) WithName(name string) logr.LogSink {
+	if s.name != "" {
+		name = s.name + "/" + name
+	}
+	return &logSink{logger: s.logger.WithScope(wlog.Fields{NameField: name}), name: name}
+}
+
+func toLogLevel(level int) wlog.LogLevel {
+	switch {
+	case level <= 0:
+		return wlog.Nfo
+	case level == 1:
+		return wlog.Dbg
+	}
+	return wlog.Trc
+}
+
+// toFields converts logr key-value pairs to Fields. Values implementing
+// logr.Marshaler are replaced by the value they marshal to
+func toFields(keysAndValues []interface{}) wlog.Fields {
+	fields := make(wlog.Fields, len(keysAndValues)/2)
+
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+
+		if i+1 == len(keysAndValues) {
+			// Odd number of arguments. Keep the key
+			fields[key] = "(MISSING)"
+			break
+		}
+
+		value := keysAndValues[i+1]
+		if m, ok := value.(logr.Marshaler); ok {
+			value = m.MarshalLog()
+		}
+		fields[key] = value
+	}
+
+	return fields
+}
@@ -0,0 +1,46 @@
+package wlogr
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vargspjut/wlog"
+)
+
+func TestLogSink(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := wlog.New(w, wlog.Dbg, false)
+
+	log := New(logger).WithName("controller").WithName("pod").WithValues("namespace", "default")
+
+	log.Info("reconciling", "attempt", 1)
+	log.V(1).Info("details")
+	log.V(2).Info("filtered")
+	log.Error(errors.New("not found"), "reconcile failed")
+
+	lines := strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 entries, got %q", lines)
+	}
+
+	for i, want := range [][]string{
+		{" NFO reconciling", "logger: controller/pod", "namespace: default", "attempt: 1"},
+		{" DBG details", "logger: controller/pod"},
+		{" ERR reconcile failed", "namespace: default", "error: not found"},
+	} {
+		for _, s := range want {
+			if !strings.Contains(lines[i], s) {
+				t.Errorf("entry %q should contain %q", lines[i], s)
+			}
+		}
+	}
+
+	logger.SetLogLevel(wlog.Trc)
+	w.Reset()
+	log.V(5).Info("trace")
+	if !strings.Contains(w.String(), " TRC trace") {
+		t.Errorf("unexpected log output %q", w.String())
+	}
+}
@@ -0,0 +1,79 @@
+package wlog
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// maxLineLength is the length at which a line that is not yet terminated
+// is logged anyway. The rest of the line is logged as separate entries
+const maxLineLength = 64 * 1024
+
+// lineWriter implements io.WriteCloser by splitting the input into lines
+// and logging each line as an entry. Lines may span several calls to Write
+type lineWriter struct {
+	logger   Logger
+	logLevel LogLevel
+	mutex    sync.Mutex
+	buf      []byte
+}
+
+func newLineWriter(logger Logger, logLevel LogLevel) io.WriteCloser {
+	return &lineWriter{logger: logger, logLevel: logLevel}
+}
+
+// Write implements io.Writer.Write
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	n := len(p)
+
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			// Keep the partial line until it's terminated
+			w.buf = append(w.buf, p...)
+			for len(w.buf) >= maxLineLength {
+				w.log(w.buf[:maxLineLength])
+				w.buf = w.buf[:copy(w.buf, w.buf[maxLineLength:])]
+			}
+			break
+		}
+
+		if len(w.buf) > 0 {
+			w.buf = append(w.buf, p[:i]...)
+			w.log(w.buf)
+			w.buf = w.buf[:0]
+		} else {
+			w.log(p[:i])
+		}
+
+		p = p[i+1:]
+	}
+
+	return n, nil
+}
+
+// Close implements io.Closer.Close. Any unterminated line is logged
+func (w *lineWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if len(w.buf) > 0 {
+		w.log(w.buf)
+		w.buf = nil
+	}
+
+	return nil
+}
+
+func (w *lineWriter) log(line []byte) {
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	if len(line) == 0 {
+		return
+	}
+
+	logAt(w.logger, w.logLevel, string(line))
+}
@@ -0,0 +1,52 @@
+package wlog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+
+	lw := logger.WithScope(Fields{"process": "worker"}).Writer(Wrn)
+
+	fmt.Fprint(lw, "first line\nsecond ")
+	fmt.Fprint(lw, "line\r\n\n")
+	fmt.Fprint(lw, "unterminated")
+
+	if got := strings.Count(w.String(), "\n"); got != 2 {
+		t.Fatalf("expected 2 entries before Close, got %d: %q", got, w.String())
+	}
+
+	if err := lw.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n")
+	want := []string{"WRN first line [process: worker]", "WRN second line [process: worker]", "WRN unterminated [process: worker]"}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d entries, got %q", len(want), lines)
+	}
+	for i := range want {
+		if !strings.HasSuffix(lines[i], want[i]) {
+			t.Errorf("entry %d = %q, want suffix %q", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestWriterLongLine(t *testing.T) {
+	w := &bytes.Buffer{}
+	logger := New(w, Nfo, false)
+
+	lw := logger.Writer(Nfo)
+	fmt.Fprint(lw, strings.Repeat("a", maxLineLength+10))
+	lw.Close()
+
+	lines := strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[1], " NFO aaaaaaaaaa") {
+		t.Errorf("expected the long line to be split in 2 entries, got %d", len(lines))
+	}
+}